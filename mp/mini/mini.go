@@ -1,6 +1,7 @@
 package mini
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
@@ -20,6 +21,7 @@ import (
 type WechatMini interface {
 	// 小程序登陆接口 https://mp.weixin.qq.com/debug/wxadoc/dev/api/api-login.html#wxloginobject
 	GetSessionKeyByCode(jsCode string) (*GetSessionKeyByCodeResponse, error)
+	GetSessionKeyByCodeWithContext(ctx context.Context, jsCode string) (*GetSessionKeyByCodeResponse, error)
 	UnEncryptFromEncryptedData(encryptedData string, sessionKey string, iv string) (*UserInfo, error)
 }
 
@@ -75,12 +77,22 @@ type wechatMini struct {
 }
 
 func (mini *wechatMini) GetSessionKeyByCode(jsCode string) (*GetSessionKeyByCodeResponse, error) {
+	return mini.GetSessionKeyByCodeWithContext(context.Background(), jsCode)
+}
+
+func (mini *wechatMini) GetSessionKeyByCodeWithContext(ctx context.Context, jsCode string) (*GetSessionKeyByCodeResponse, error) {
 	url := fmt.Sprintf(get_sessionkey_url, mini.appId, mini.secret, jsCode)
 
-	result, err := mini.client.Get(url)
+	request, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	result, err := mini.client.Do(request)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	defer result.Body.Close()
 
 	body, err := ioutil.ReadAll(result.Body)
 	if err != nil {
@@ -0,0 +1,407 @@
+package pay
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+下载交易账单
+https://pay.weixin.qq.com/wiki/doc/api/jsapi.php?chapter=9_6
+*/
+
+const (
+	DOWNLOAD_BILL_URL = "https://api.mch.weixin.qq.com/pay/downloadbill"
+)
+
+type BillType string
+
+const (
+	BILL_TYPE_ALL             BillType = "ALL"             // 当日所有订单信息（不含充值退款订单）
+	BILL_TYPE_SUCCESS         BillType = "SUCCESS"         // 当日成功支付的订单（不含充值退款订单）
+	BILL_TYPE_REFUND          BillType = "REFUND"          // 当日退款订单（不含充值退款订单）
+	BILL_TYPE_RECHARGE_REFUND BillType = "RECHARGE_REFUND" // 当日充值退款订单
+)
+
+type DownloadBillParam struct {
+	AppId    string `xml:"appid"`
+	Mchid    string `xml:"mch_id"`
+	NonceStr string `xml:"nonce_str"`
+	Sign     string `xml:"sign"`
+	BillDate string `xml:"bill_date"` // 对账单日期，格式：20140603
+	BillType string `xml:"bill_type"`
+	TarType  string `xml:"tar_type"` // 固定值 GZIP，不传则返回原始数据流
+}
+
+// 账单中的一笔交易，除手续费外金额单位为分
+// 不同 bill_type 的账单列不同，账单中不存在的列保持零值，全部列的原始值保存在 Fields 中
+type BillRecord struct {
+	TradeTime               string // 交易时间
+	AppId                   string // 公众账号ID
+	MchId                   string // 商户号
+	SubMchId                string // 特约商户号
+	DeviceInfo              string // 设备号
+	TransactionId           string // 微信订单号
+	OutTradeNo              string // 商户订单号
+	Openid                  string // 用户标识
+	TradeType               string // 交易类型
+	TradeState              string // 交易状态
+	BankType                string // 付款银行
+	FeeType                 string // 货币种类
+	SettlementTotalFee      int64  // 应结订单金额
+	CouponFee               int64  // 代金券金额
+	RefundApplyTime         string // 退款申请时间
+	RefundSuccessTime       string // 退款成功时间
+	RefundId                string // 微信退款单号
+	OutRefundNo             string // 商户退款单号
+	RefundFee               int64  // 退款金额
+	RechargeCouponRefundFee int64  // 充值券退款金额
+	RefundType              string // 退款类型
+	RefundStatus            string // 退款状态
+	Body                    string // 商品名称
+	Attach                  string // 商户数据包
+	PoundageFee             string // 手续费，单位为元，保留小数点后5位，因此不转换为分
+	Rate                    string // 费率
+	TotalFee                int64  // 订单金额
+	ApplyRefundFee          int64  // 申请退款金额
+	RateNotes               string // 费率备注
+
+	Fields map[string]string // 列名 -> 原始值
+}
+
+// 账单汇总，除手续费外金额单位为分
+type BillSummary struct {
+	TotalCount              int64  // 总交易单数
+	SettlementTotalFee      int64  // 应结订单总金额
+	RefundFee               int64  // 退款总金额
+	RechargeCouponRefundFee int64  // 充值券退款总金额
+	PoundageFee             string // 手续费总金额，单位为元
+	TotalFee                int64  // 订单总金额
+	ApplyRefundFee          int64  // 申请退款总金额
+
+	Fields map[string]string // 列名 -> 原始值
+}
+
+// 流式读取交易账单，使用完毕后需要调用 Close
+type BillReader struct {
+	scanner *billScanner
+	summary *BillSummary
+}
+
+func (r *BillReader) Next() (*BillRecord, error) {
+	fields, err := r.scanner.next()
+	if err == io.EOF && r.summary == nil && r.scanner.summary != nil {
+		if r.summary, err = parseBillSummary(r.scanner.summary); err == nil {
+			err = io.EOF
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return parseBillRecord(fields)
+}
+
+// 账单汇总，Next 返回 io.EOF 之后可用，账单没有汇总数据时返回 nil
+func (r *BillReader) Summary() *BillSummary {
+	return r.summary
+}
+
+func (r *BillReader) Close() error {
+	return r.scanner.Close()
+}
+
+// 下载交易账单，返回的 BillReader 逐行解析账单，不会将整个账单读入内存
+// gzipped 为 true 时请求压缩后的账单，由 BillReader 解压
+func (self *wechatPay) DownloadBill(billDate time.Time, billType BillType, gzipped bool) (*BillReader, error) {
+	return self.DownloadBillWithContext(context.Background(), billDate, billType, gzipped)
+}
+
+func (self *wechatPay) DownloadBillWithContext(ctx context.Context, billDate time.Time, billType BillType, gzipped bool) (*BillReader, error) {
+	param := &DownloadBillParam{
+		AppId:    self.AppId,
+		Mchid:    self.mchId,
+		NonceStr: randString(self.NonceLen),
+		BillDate: billDate.Format("20060102"),
+		BillType: string(billType),
+	}
+
+	if gzipped {
+		param.TarType = "GZIP"
+	}
+
+	sign, err := self.signRequest(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	param.Sign = sign
+
+	result, err := self.doRequest(ctx, self.nonSecureClient, DOWNLOAD_BILL_URL, param)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner, err := newBillScanner(result.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BillReader{scanner: scanner}, nil
+}
+
+// 逐行读取账单类数据：第一行为表头，之后为以 ` 开头的数据行，最后为汇总表头与汇总数据
+type billScanner struct {
+	body   io.ReadCloser
+	reader *bufio.Reader
+
+	header  []string
+	summary map[string]string
+}
+
+// 读取成功时返回的是账单数据（可能经过 gzip 压缩），失败时返回 xml，此时返回 *ResultError
+func newBillScanner(body io.ReadCloser) (*billScanner, error) {
+	reader := bufio.NewReader(body)
+
+	head, err := reader.Peek(5)
+	if err != nil && err != io.EOF {
+		body.Close()
+		return nil, err
+	}
+
+	if bytes.HasPrefix(head, []byte("<xml>")) {
+		defer body.Close()
+
+		data, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+
+		values, err := parseXMLMap(data)
+		if err != nil {
+			return nil, &FormatError{Err: err}
+		}
+
+		if err := checkResult(values); err != nil {
+			return nil, err
+		}
+
+		return nil, &FormatError{Err: errors.New("unexpected xml response for bill")}
+	}
+
+	if bytes.HasPrefix(head, []byte{0x1f, 0x8b}) {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			body.Close()
+			return nil, err
+		}
+
+		reader = bufio.NewReader(gzipReader)
+	}
+
+	return &billScanner{
+		body:   body,
+		reader: reader,
+	}, nil
+}
+
+// 返回一行数据，列名 -> 值；数据行读取完毕后返回 io.EOF，此时汇总数据保存在 summary 中
+func (s *billScanner) next() (map[string]string, error) {
+	for {
+		line, err := s.readLine()
+		if err != nil {
+			return nil, err
+		}
+
+		if s.header == nil {
+			s.header = strings.Split(line, ",")
+			continue
+		}
+
+		if !strings.HasPrefix(line, "`") {
+			summaryHeader := strings.Split(line, ",")
+
+			values, err := s.readLine()
+			if err == io.EOF {
+				return nil, &FormatError{Err: errors.New("bill summary missing")}
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			if s.summary, err = zipBillFields(summaryHeader, values); err != nil {
+				return nil, err
+			}
+
+			return nil, io.EOF
+		}
+
+		return zipBillFields(s.header, line)
+	}
+}
+
+// 读取下一个非空行，去掉换行符与 BOM
+func (s *billScanner) readLine() (string, error) {
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", err
+		}
+
+		line = strings.TrimPrefix(strings.TrimRight(line, "\r\n"), "\ufeff")
+		if line != "" {
+			return line, nil
+		}
+	}
+}
+
+func (s *billScanner) Close() error {
+	return s.body.Close()
+}
+
+// 数据行的每个值都以 ` 开头，值中可能包含逗号，因此以 ",`" 分隔
+func zipBillFields(header []string, line string) (map[string]string, error) {
+	values := strings.Split(strings.TrimPrefix(line, "`"), ",`")
+	if len(values) != len(header) {
+		return nil, &FormatError{Err: errors.New(fmt.Sprintf("bill row has %d fields, header has %d", len(values), len(header)))}
+	}
+
+	fields := make(map[string]string, len(header))
+	for i, name := range header {
+		fields[strings.TrimSpace(name)] = values[i]
+	}
+
+	return fields, nil
+}
+
+func parseBillRecord(fields map[string]string) (*BillRecord, error) {
+	record := &BillRecord{
+		TradeTime:         fields["交易时间"],
+		AppId:             fields["公众账号ID"],
+		MchId:             fields["商户号"],
+		SubMchId:          fields["特约商户号"],
+		DeviceInfo:        fields["设备号"],
+		TransactionId:     fields["微信订单号"],
+		OutTradeNo:        fields["商户订单号"],
+		Openid:            fields["用户标识"],
+		TradeType:         fields["交易类型"],
+		TradeState:        fields["交易状态"],
+		BankType:          fields["付款银行"],
+		FeeType:           fields["货币种类"],
+		RefundApplyTime:   fields["退款申请时间"],
+		RefundSuccessTime: fields["退款成功时间"],
+		RefundId:          fields["微信退款单号"],
+		OutRefundNo:       fields["商户退款单号"],
+		RefundType:        fields["退款类型"],
+		RefundStatus:      fields["退款状态"],
+		Body:              fields["商品名称"],
+		Attach:            fields["商户数据包"],
+		PoundageFee:       fields["手续费"],
+		Rate:              fields["费率"],
+		RateNotes:         fields["费率备注"],
+		Fields:            fields,
+	}
+
+	amounts := map[string]*int64{
+		"应结订单金额":  &record.SettlementTotalFee,
+		"代金券金额":   &record.CouponFee,
+		"退款金额":    &record.RefundFee,
+		"充值券退款金额": &record.RechargeCouponRefundFee,
+		"订单金额":    &record.TotalFee,
+		"申请退款金额":  &record.ApplyRefundFee,
+	}
+	if err := parseBillAmounts(fields, amounts); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+func parseBillSummary(fields map[string]string) (*BillSummary, error) {
+	summary := &BillSummary{
+		PoundageFee: fields["手续费总金额"],
+		Fields:      fields,
+	}
+
+	if count := fields["总交易单数"]; count != "" {
+		value, err := strconv.ParseInt(count, 10, 64)
+		if err != nil {
+			return nil, &FormatError{Err: err}
+		}
+		summary.TotalCount = value
+	}
+
+	amounts := map[string]*int64{
+		"应结订单总金额":  &summary.SettlementTotalFee,
+		"退款总金额":    &summary.RefundFee,
+		"充值券退款总金额": &summary.RechargeCouponRefundFee,
+		"订单总金额":    &summary.TotalFee,
+		"申请退款总金额":  &summary.ApplyRefundFee,
+	}
+	if err := parseBillAmounts(fields, amounts); err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+func parseBillAmounts(fields map[string]string, amounts map[string]*int64) error {
+	for name, amount := range amounts {
+		value, ok := fields[name]
+		if !ok || value == "" {
+			continue
+		}
+
+		fen, err := yuanToFen(value)
+		if err != nil {
+			return &FormatError{Err: errors.New(fmt.Sprintf("invalid %v: %v", name, value))}
+		}
+		*amount = fen
+	}
+
+	return nil
+}
+
+// 将以元为单位的金额字符串（如 "-1.5"、"0.01"）精确转换为分
+func yuanToFen(yuan string) (int64, error) {
+	yuan = strings.TrimSpace(yuan)
+
+	negative := strings.HasPrefix(yuan, "-")
+	yuan = strings.TrimPrefix(yuan, "-")
+
+	parts := strings.SplitN(yuan, ".", 2)
+	integer, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	fen := integer * 100
+	if len(parts) == 2 {
+		decimal := parts[1]
+		if len(decimal) > 2 {
+			return 0, errors.New("more than two decimal places: " + yuan)
+		}
+		decimal = (decimal + "00")[:2]
+
+		value, err := strconv.ParseInt(decimal, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		fen += value
+	}
+
+	if negative {
+		fen = -fen
+	}
+
+	return fen, nil
+}
@@ -0,0 +1,88 @@
+package pay
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+const testBill = "\ufeff交易时间,公众账号ID,商户号,特约商户号,设备号,微信订单号,商户订单号,用户标识,交易类型,交易状态,付款银行,货币种类,应结订单金额,代金券金额,微信退款单号,商户退款单号,退款金额,充值券退款金额,退款类型,退款状态,商品名称,商户数据包,手续费,费率,订单金额,申请退款金额,费率备注\r\n" +
+	"`2017-12-01 00:00:02,`wx0,`1000,`0,`,`t0,`o0,`openid0,`JSAPI,`SUCCESS,`CFT,`CNY,`1.50,`0.00,`0,`0,`0.00,`0.00,`,`,`商品,逗号,`,`0.01000,`0.60%,`1.50,`0.00,`\r\n" +
+	"`2017-12-01 00:00:03,`wx0,`1000,`0,`,`t1,`o1,`openid1,`JSAPI,`REFUND,`CFT,`CNY,`0.00,`0.00,`r1,`or1,`-0.1,`0.00,`ORIGINAL,`SUCCESS,`商品,`,`-0.00100,`0.60%,`0.00,`0.10,`\r\n" +
+	"总交易单数,应结订单总金额,退款总金额,充值券退款总金额,手续费总金额,订单总金额,申请退款总金额\r\n" +
+	"`2,`1.50,`0.10,`0.00,`0.01000,`1.50,`0.10\r\n"
+
+func readTestBill(t *testing.T, body []byte) ([]*BillRecord, *BillSummary) {
+	scanner, err := newBillScanner(ioutil.NopCloser(bytes.NewReader(body)))
+	if err != nil {
+		t.Fatalf("newBillScanner return err: %v", err)
+	}
+
+	reader := &BillReader{scanner: scanner}
+	defer reader.Close()
+
+	records := make([]*BillRecord, 0)
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next return err: %v", err)
+		}
+		records = append(records, record)
+	}
+
+	return records, reader.Summary()
+}
+
+func Test_BillReader(t *testing.T) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	writer.Write([]byte(testBill))
+	writer.Close()
+
+	for _, body := range [][]byte{[]byte(testBill), compressed.Bytes()} {
+		records, summary := readTestBill(t, body)
+
+		if len(records) != 2 {
+			t.Fatalf("BillReader fail for records. want: 2. get: %v", len(records))
+		}
+
+		if records[0].TransactionId != "t0" || records[0].SettlementTotalFee != 150 || records[0].Body != "商品,逗号" || records[0].Rate != "0.60%" {
+			t.Errorf("BillReader fail for record 0. get: %+v", records[0])
+		}
+
+		if records[1].RefundId != "r1" || records[1].RefundFee != -10 || records[1].ApplyRefundFee != 10 {
+			t.Errorf("BillReader fail for record 1. get: %+v", records[1])
+		}
+
+		if summary == nil || summary.TotalCount != 2 || summary.SettlementTotalFee != 150 || summary.RefundFee != 10 {
+			t.Errorf("BillReader fail for summary. get: %+v", summary)
+		}
+	}
+}
+
+func Test_newBillScanner_fail(t *testing.T) {
+	body := []byte("<xml><return_code><![CDATA[FAIL]]></return_code><return_msg><![CDATA[No Bill Exist]]></return_msg></xml>")
+
+	_, err := newBillScanner(ioutil.NopCloser(bytes.NewReader(body)))
+	if e, ok := err.(*ResultError); !ok || e.ReturnMsg != "No Bill Exist" {
+		t.Errorf("newBillScanner should return *ResultError. get: %v", err)
+	}
+}
+
+func Test_yuanToFen(t *testing.T) {
+	cases := map[string]int64{"0": 0, "0.01": 1, "1.5": 150, "-0.10": -10, "123.45": 12345}
+	for yuan, want := range cases {
+		if fen, err := yuanToFen(yuan); err != nil || fen != want {
+			t.Errorf("yuanToFen fail for %v. want: %v. get: %v, %v", yuan, want, fen, err)
+		}
+	}
+
+	if _, err := yuanToFen("0.001"); err == nil {
+		t.Errorf("yuanToFen should fail for 0.001")
+	}
+}
@@ -0,0 +1,104 @@
+package pay
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+/*
+客户端调起支付所需的参数，由统一下单返回的 prepay_id 生成
+JSAPI: https://pay.weixin.qq.com/wiki/doc/api/jsapi.php?chapter=7_7&index=6
+小程序: https://pay.weixin.qq.com/wiki/doc/api/wxa/wxa_api.php?chapter=7_7&index=5
+APP: https://pay.weixin.qq.com/wiki/doc/api/app/app.php?chapter=9_12&index=2
+字段名大小写与客户端要求一致，可直接编码为 json 交给前端
+*/
+
+const (
+	APP_PAY_PACKAGE = "Sign=WXPay"
+)
+
+// 公众号 WeixinJSBridge.invoke('getBrandWCPayRequest') 与小程序 wx.requestPayment 的参数
+// 小程序调用时不需要 appId，但 appId 参与签名
+type JSAPIPayParams struct {
+	AppId     string `xml:"appId" json:"appId"`
+	TimeStamp string `xml:"timeStamp" json:"timeStamp"`
+	NonceStr  string `xml:"nonceStr" json:"nonceStr"`
+	Package   string `xml:"package" json:"package"` // prepay_id=xxx
+	SignType  string `xml:"signType" json:"signType"`
+	PaySign   string `xml:"-" json:"paySign"`
+}
+
+// APP 端 SDK 调起支付的参数
+type AppPayParams struct {
+	AppId     string `xml:"appid" json:"appid"`
+	PartnerId string `xml:"partnerid" json:"partnerid"` // 商户号
+	PrepayId  string `xml:"prepayid" json:"prepayid"`
+	Package   string `xml:"package" json:"package"` // 固定为 Sign=WXPay
+	NonceStr  string `xml:"noncestr" json:"noncestr"`
+	TimeStamp string `xml:"timestamp" json:"timestamp"`
+	Sign      string `xml:"sign" json:"sign"`
+}
+
+// 生成公众号、小程序调起支付的参数，签名算法需要与统一下单时一致，下单时指定了签名算法时需要传入相同的 WithRequestSignType
+func (self *wechatPay) JSAPIPayParams(resp *UnifiedOrderResponse, options ...RequestOption) (*JSAPIPayParams, error) {
+	if err := checkPrepay(resp, TRADE_TYPE_JSAPI); err != nil {
+		return nil, err
+	}
+
+	signType := self.requestOption(options).signType
+	params := &JSAPIPayParams{
+		AppId:     self.AppId,
+		TimeStamp: strconv.FormatInt(time.Now().Unix(), 10),
+		NonceStr:  randString(self.NonceLen),
+		Package:   "prepay_id=" + resp.PrePayId,
+		SignType:  string(signType),
+	}
+
+	sign, err := self.signWithType(params, signType)
+	if err != nil {
+		return nil, err
+	}
+
+	params.PaySign = sign
+
+	return params, nil
+}
+
+// 生成 APP 调起支付的参数，APP 端只支持 MD5 签名
+func (self *wechatPay) AppPayParams(resp *UnifiedOrderResponse) (*AppPayParams, error) {
+	if err := checkPrepay(resp, TRADE_TYPE_APP); err != nil {
+		return nil, err
+	}
+
+	params := &AppPayParams{
+		AppId:     self.AppId,
+		PartnerId: self.mchId,
+		PrepayId:  resp.PrePayId,
+		Package:   APP_PAY_PACKAGE,
+		NonceStr:  randString(self.NonceLen),
+		TimeStamp: strconv.FormatInt(time.Now().Unix(), 10),
+	}
+
+	sign, err := self.signWithType(params, SIGN_TYPE_MD5)
+	if err != nil {
+		return nil, err
+	}
+
+	params.Sign = sign
+
+	return params, nil
+}
+
+func checkPrepay(resp *UnifiedOrderResponse, tradeType TradeType) error {
+	if resp == nil || resp.PrePayId == "" {
+		return errors.New("prepay_id is empty")
+	}
+
+	if resp.TradeType != "" && resp.TradeType != string(tradeType) {
+		return errors.New(fmt.Sprintf("trade_type not match. want: %v. get: %v", tradeType, resp.TradeType))
+	}
+
+	return nil
+}
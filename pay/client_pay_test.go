@@ -0,0 +1,46 @@
+package pay
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func Test_wechatPay_JSAPIPayParams(t *testing.T) {
+	client := &wechatPay{AppId: "wx0", apiSignKey: "test-Sign-key", NonceLen: 16, signType: SIGN_TYPE_MD5}
+
+	params, err := client.JSAPIPayParams(&UnifiedOrderResponse{TradeType: string(TRADE_TYPE_JSAPI), PrePayId: "wx201410272009395522657a690389285100"})
+	if err != nil {
+		t.Fatalf("JSAPIPayParams return err: %v", err)
+	}
+
+	content := "appId=wx0&nonceStr=" + params.NonceStr + "&package=prepay_id=wx201410272009395522657a690389285100&signType=MD5&timeStamp=" + params.TimeStamp + "&key=test-Sign-key"
+	if want := strings.ToUpper(md5Str(content)); params.PaySign != want {
+		t.Errorf("JSAPIPayParams fail for paySign. want: %v. get: %v", want, params.PaySign)
+	}
+
+	data, _ := json.Marshal(params)
+	for _, name := range []string{`"appId"`, `"timeStamp"`, `"nonceStr"`, `"package"`, `"signType"`, `"paySign"`} {
+		if !strings.Contains(string(data), name) {
+			t.Errorf("JSAPIPayParams json missing %v. get: %s", name, data)
+		}
+	}
+
+	if _, err := client.JSAPIPayParams(&UnifiedOrderResponse{TradeType: string(TRADE_TYPE_APP), PrePayId: "p0"}); err == nil {
+		t.Errorf("JSAPIPayParams should fail for APP prepay")
+	}
+}
+
+func Test_wechatPay_AppPayParams(t *testing.T) {
+	client := &wechatPay{AppId: "wx0", mchId: "10000100", apiSignKey: "test-Sign-key", NonceLen: 16, signType: SIGN_TYPE_HMAC_SHA256}
+
+	params, err := client.AppPayParams(&UnifiedOrderResponse{TradeType: string(TRADE_TYPE_APP), PrePayId: "p0"})
+	if err != nil {
+		t.Fatalf("AppPayParams return err: %v", err)
+	}
+
+	content := "appid=wx0&noncestr=" + params.NonceStr + "&package=Sign=WXPay&partnerid=10000100&prepayid=p0&timestamp=" + params.TimeStamp + "&key=test-Sign-key"
+	if want := strings.ToUpper(md5Str(content)); params.Sign != want {
+		t.Errorf("AppPayParams fail for sign. want: %v. get: %v", want, params.Sign)
+	}
+}
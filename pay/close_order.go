@@ -0,0 +1,78 @@
+package pay
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+)
+
+// 微信支付关闭订单接口
+// https://pay.weixin.qq.com/wiki/doc/api/jsapi.php?chapter=9_3
+
+const (
+	CLOSE_ORDER_URL = "https://api.mch.weixin.qq.com/pay/closeorder"
+)
+
+var (
+	ErrOrderPaid   = errors.New("order paid, can not close")          // 订单已支付，不能关闭，应按支付成功处理
+	ErrOrderClosed = errors.New("order closed")                       // 订单已关闭，无需重复关闭
+	ErrSystemError = errors.New("wechat system error, need to retry") // 系统异常，需要重新调用
+)
+
+type CloseOrderParam struct {
+	AppId      string `xml:"appid"`
+	Mchid      string `xml:"mch_id"`
+	OutTradeNo string `xml:"out_trade_no"` // 商户订单号
+	NonceStr   string `xml:"nonce_str"`
+	Sign       string `xml:"sign"`
+	SignType   string `xml:"sign_type"`
+}
+
+type CloseOrderResponse struct {
+	ReturnCode string `xml:"return_code"`
+	ReturnMsg  string `xml:"return_msg"`
+	AppId      string `xml:"appid"`
+	MchId      string `xml:"mch_id"`
+	NonceStr   string `xml:"nonce_str"`
+	Sign       string `xml:"sign"`
+	ResultCode string `xml:"result_code"`
+	ResultMsg  string `xml:"result_msg"`
+	ErrCode    string `xml:"err_code"`
+	ErrCodeDes string `xml:"err_code_des"`
+}
+
+// 关闭订单。订单生成后不能马上调用关单接口，最短调用时间间隔为5分钟
+// 业务失败返回 *ResultError，可以用 errors.Is 判断 ErrOrderPaid、ErrOrderClosed、ErrSystemError
+// 订单已支付时应按支付成功处理，订单已关闭时无需重复关闭，系统异常时可以重试
+func (self *wechatPay) CloseOrder(outTradeNo string, options ...RequestOption) (*CloseOrderResponse, error) {
+	return self.CloseOrderWithContext(context.Background(), outTradeNo, options...)
+}
+
+func (self *wechatPay) CloseOrderWithContext(ctx context.Context, outTradeNo string, options ...RequestOption) (*CloseOrderResponse, error) {
+	param := &CloseOrderParam{
+		AppId:      self.AppId,
+		Mchid:      self.mchId,
+		OutTradeNo: outTradeNo,
+		NonceStr:   randString(self.NonceLen),
+		SignType:   string(self.requestOption(options).signType),
+	}
+
+	sign, err := self.signRequest(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	param.Sign = sign
+
+	data, _, err := self.post(ctx, self.nonSecureClient, CLOSE_ORDER_URL, param)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &CloseOrderResponse{}
+	if err = xml.Unmarshal(data, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
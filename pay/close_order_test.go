@@ -0,0 +1,43 @@
+package pay
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func Test_wechatPay_CloseOrder(t *testing.T) {
+	var values map[string]string
+	client := &wechatPay{
+		apiSignKey: pay.apiSignKey,
+		NonceLen:   16,
+		signType:   SIGN_TYPE_MD5,
+		nonSecureClient: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewReader(signedNotifyBody(values))), Header: http.Header{}}, nil
+		})},
+	}
+
+	values = map[string]string{"return_code": "SUCCESS", "result_code": "SUCCESS"}
+	if resp, err := client.CloseOrder("o0"); err != nil || resp.ResultCode != "SUCCESS" {
+		t.Errorf("CloseOrder fail. get: %+v, %v", resp, err)
+	}
+
+	cases := map[ErrCode]error{
+		ERR_CODE_ORDERPAID:   ErrOrderPaid,
+		ERR_CODE_ORDERCLOSED: ErrOrderClosed,
+		ERR_CODE_SYSTEMERROR: ErrSystemError,
+	}
+	for errCode, want := range cases {
+		values = map[string]string{"return_code": "SUCCESS", "result_code": "FAIL", "err_code": string(errCode)}
+
+		_, err := client.CloseOrder("o0")
+		if !errors.Is(err, want) {
+			t.Errorf("CloseOrder should match %v for %v. get: %v", want, errCode, err)
+		}
+		if _, ok := err.(*ResultError); !ok {
+			t.Errorf("CloseOrder should return *ResultError for %v. get: %T", errCode, err)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package pay
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+)
+
+/*
+代金券
+发放代金券: https://pay.weixin.qq.com/wiki/doc/api/tools/sp_coupon.php?chapter=12_3&index=4
+查询代金券批次: https://pay.weixin.qq.com/wiki/doc/api/tools/sp_coupon.php?chapter=12_4&index=5
+查询代金券信息: https://pay.weixin.qq.com/wiki/doc/api/tools/sp_coupon.php?chapter=12_5&index=6
+*/
+
+const (
+	SEND_COUPON_URL        = "https://api.mch.weixin.qq.com/mmpaymkttransfers/send_coupon"
+	QUERY_COUPON_STOCK_URL = "https://api.mch.weixin.qq.com/mmpaymkttransfers/query_coupon_stock"
+	QUERY_COUPONS_INFO_URL = "https://api.mch.weixin.qq.com/mmpaymkttransfers/querycouponsinfo"
+)
+
+type CouponStockStatus int
+
+const (
+	COUPON_STOCK_STATUS_INACTIVE  CouponStockStatus = 1  // 未激活
+	COUPON_STOCK_STATUS_AUDITING  CouponStockStatus = 2  // 审批中
+	COUPON_STOCK_STATUS_ACTIVATED CouponStockStatus = 4  // 已激活
+	COUPON_STOCK_STATUS_CANCELED  CouponStockStatus = 8  // 已作废
+	COUPON_STOCK_STATUS_STOPPED   CouponStockStatus = 16 // 中止发放
+)
+
+type CouponState int
+
+const (
+	COUPON_STATE_ACTIVATED CouponState = 2 // 已激活
+	COUPON_STATE_LOCKED    CouponState = 4 // 已锁定
+	COUPON_STATE_USED      CouponState = 8 // 已实扣
+)
+
+type sendCouponParam struct {
+	CouponStockId  string `xml:"coupon_stock_id"`
+	OpenidCount    int    `xml:"openid_count"` // 固定为1
+	PartnerTradeNo string `xml:"partner_trade_no"`
+	Openid         string `xml:"openid"`
+	AppId          string `xml:"appid"`
+	Mchid          string `xml:"mch_id"`
+	OpUserId       string `xml:"op_user_id"` // 操作员帐号，默认为商户号
+	DeviceInfo     string `xml:"device_info"`
+	NonceStr       string `xml:"nonce_str"`
+	Sign           string `xml:"sign"`
+	Version        string `xml:"version"` // 固定为 1.0
+	Type           string `xml:"type"`    // 固定为 XML
+}
+
+type SendCouponResponse struct {
+	ReturnCode string `xml:"return_code"`
+	ReturnMsg  string `xml:"return_msg"`
+	AppId      string `xml:"appid"`
+	MchId      string `xml:"mch_id"`
+	DeviceInfo string `xml:"device_info"`
+	NonceStr   string `xml:"nonce_str"`
+	Sign       string `xml:"sign"`
+	ResultCode string `xml:"result_code"`
+	ErrCode    string `xml:"err_code"`
+	ErrCodeDes string `xml:"err_code_des"`
+
+	CouponStockId string `xml:"coupon_stock_id"`
+	RespCount     int    `xml:"resp_count"`    // 返回记录数
+	SuccessCount  int    `xml:"success_count"` // 成功记录数
+	FailedCount   int    `xml:"failed_count"`  // 失败记录数
+	Openid        string `xml:"openid"`
+	RetCode       string `xml:"ret_code"`  // 单个用户的发放结果，SUCCESS/FAILED
+	CouponId      string `xml:"coupon_id"` // 发放成功时的代金券id
+	RetMsg        string `xml:"ret_msg"`   // 失败描述信息
+}
+
+type queryCouponStockParam struct {
+	CouponStockId string `xml:"coupon_stock_id"`
+	AppId         string `xml:"appid"`
+	Mchid         string `xml:"mch_id"`
+	OpUserId      string `xml:"op_user_id"`
+	DeviceInfo    string `xml:"device_info"`
+	NonceStr      string `xml:"nonce_str"`
+	Sign          string `xml:"sign"`
+	Version       string `xml:"version"`
+	Type          string `xml:"type"`
+}
+
+type CouponStockResponse struct {
+	ReturnCode string `xml:"return_code"`
+	ReturnMsg  string `xml:"return_msg"`
+	AppId      string `xml:"appid"`
+	MchId      string `xml:"mch_id"`
+	DeviceInfo string `xml:"device_info"`
+	NonceStr   string `xml:"nonce_str"`
+	Sign       string `xml:"sign"`
+	ResultCode string `xml:"result_code"`
+	ErrCode    string `xml:"err_code"`
+	ErrCodeDes string `xml:"err_code_des"`
+
+	CouponStockId     string            `xml:"coupon_stock_id"`
+	CouponName        string            `xml:"coupon_name"`
+	CouponValue       int64             `xml:"coupon_value"`    // 代金券面额，单位为分
+	CouponMininumn    int64             `xml:"coupon_mininumn"` // 代金券使用最低限额，微信字段名即为 mininumn
+	CouponStockStatus CouponStockStatus `xml:"coupon_stock_status"`
+	CouponTotal       int64             `xml:"coupon_total"` // 代金券数量
+	MaxQuota          int64             `xml:"max_quota"`    // 每个用户最多能领取的代金券数量
+	IsSendNum         int64             `xml:"is_send_num"`  // 已经发送的代金券数量
+	BeginTime         string            `xml:"begin_time"`   // 生效开始时间
+	EndTime           string            `xml:"end_time"`     // 生效结束时间
+	CreateTime        string            `xml:"create_time"`
+	CouponBudget      int64             `xml:"coupon_budget"` // 代金券预算额度
+}
+
+type queryCouponsInfoParam struct {
+	CouponId   string `xml:"coupon_id"`
+	Openid     string `xml:"openid"`
+	AppId      string `xml:"appid"`
+	Mchid      string `xml:"mch_id"`
+	StockId    string `xml:"stock_id"`
+	OpUserId   string `xml:"op_user_id"`
+	DeviceInfo string `xml:"device_info"`
+	NonceStr   string `xml:"nonce_str"`
+	Sign       string `xml:"sign"`
+	Version    string `xml:"version"`
+	Type       string `xml:"type"`
+}
+
+type CouponInfoResponse struct {
+	ReturnCode string `xml:"return_code"`
+	ReturnMsg  string `xml:"return_msg"`
+	AppId      string `xml:"appid"`
+	MchId      string `xml:"mch_id"`
+	DeviceInfo string `xml:"device_info"`
+	NonceStr   string `xml:"nonce_str"`
+	Sign       string `xml:"sign"`
+	ResultCode string `xml:"result_code"`
+	ErrCode    string `xml:"err_code"`
+	ErrCodeDes string `xml:"err_code_des"`
+
+	CouponStockId     string      `xml:"coupon_stock_id"`
+	CouponId          string      `xml:"coupon_id"`
+	CouponValue       int64       `xml:"coupon_value"`   // 代金券面值，单位为分
+	CouponMininum     int64       `xml:"coupon_mininum"` // 代金券使用最低限额，微信字段名即为 mininum
+	CouponName        string      `xml:"coupon_name"`
+	CouponState       CouponState `xml:"coupon_state"`
+	CouponDesc        string      `xml:"coupon_desc"`
+	CouponUseValue    int64       `xml:"coupon_use_value"`    // 代金券实际使用金额
+	CouponRemainValue int64       `xml:"coupon_remain_value"` // 代金券剩余金额
+	BeginTime         string      `xml:"begin_time"`
+	EndTime           string      `xml:"end_time"`
+	SendTime          string      `xml:"send_time"`
+	UseTime           string      `xml:"use_time"`
+	TradeNo           string      `xml:"trade_no"` // 使用单号
+	ConsumerMchId     string      `xml:"consumer_mch_id"`
+	ConsumerMchName   string      `xml:"consumer_mch_name"`
+	ConsumerMchAppid  string      `xml:"consumer_mch_appid"`
+	SendSource        string      `xml:"send_source"`    // FULL_SEND-满送，NORMAL-普通发劵场景，OTHER-其他场景
+	IsPartialUse      string      `xml:"is_partial_use"` // 1-表示支持部分使用，0-表示不支持
+}
+
+// 请求成功但未能向用户发放代金券，ret_code 不为 SUCCESS
+type CouponSendError struct {
+	RetCode string
+	RetMsg  string
+}
+
+func (e *CouponSendError) Error() string {
+	return fmt.Sprintf("send coupon fail. ret_code: %v, ret_msg: %v", e.RetCode, e.RetMsg)
+}
+
+// 发放代金券，需要证书
+// 用户的发放结果 ret_code 不为 SUCCESS 时，同时返回 *SendCouponResponse 与 *CouponSendError
+func (self *wechatPay) SendCoupon(couponStockId, partnerTradeNo, openId string) (*SendCouponResponse, error) {
+	return self.SendCouponWithContext(context.Background(), couponStockId, partnerTradeNo, openId)
+}
+
+func (self *wechatPay) SendCouponWithContext(ctx context.Context, couponStockId, partnerTradeNo, openId string) (*SendCouponResponse, error) {
+	param := &sendCouponParam{
+		CouponStockId:  couponStockId,
+		OpenidCount:    1,
+		PartnerTradeNo: partnerTradeNo,
+		Openid:         openId,
+		AppId:          self.AppId,
+		Mchid:          self.mchId,
+		OpUserId:       self.mchId,
+		NonceStr:       randString(self.NonceLen),
+		Version:        "1.0",
+		Type:           "XML",
+	}
+
+	sign, err := self.signRequest(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	param.Sign = sign
+
+	data, _, err := self.post(ctx, self.secureClient, SEND_COUPON_URL, param)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &SendCouponResponse{}
+	if err = xml.Unmarshal(data, resp); err != nil {
+		return nil, err
+	}
+
+	// result_code 为 SUCCESS 只表示请求成功，用户是否领到代金券以 ret_code 为准
+	if resp.RetCode != "SUCCESS" {
+		return resp, &CouponSendError{RetCode: resp.RetCode, RetMsg: resp.RetMsg}
+	}
+
+	return resp, nil
+}
+
+// 查询代金券批次
+func (self *wechatPay) QueryCouponStock(couponStockId string) (*CouponStockResponse, error) {
+	return self.QueryCouponStockWithContext(context.Background(), couponStockId)
+}
+
+func (self *wechatPay) QueryCouponStockWithContext(ctx context.Context, couponStockId string) (*CouponStockResponse, error) {
+	param := &queryCouponStockParam{
+		CouponStockId: couponStockId,
+		AppId:         self.AppId,
+		Mchid:         self.mchId,
+		OpUserId:      self.mchId,
+		NonceStr:      randString(self.NonceLen),
+		Version:       "1.0",
+		Type:          "XML",
+	}
+
+	sign, err := self.signRequest(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	param.Sign = sign
+
+	data, _, err := self.post(ctx, self.nonSecureClient, QUERY_COUPON_STOCK_URL, param)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &CouponStockResponse{}
+	if err = xml.Unmarshal(data, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// 查询用户的代金券信息
+func (self *wechatPay) QueryCouponsInfo(couponId, openId, stockId string) (*CouponInfoResponse, error) {
+	return self.QueryCouponsInfoWithContext(context.Background(), couponId, openId, stockId)
+}
+
+func (self *wechatPay) QueryCouponsInfoWithContext(ctx context.Context, couponId, openId, stockId string) (*CouponInfoResponse, error) {
+	param := &queryCouponsInfoParam{
+		CouponId: couponId,
+		Openid:   openId,
+		AppId:    self.AppId,
+		Mchid:    self.mchId,
+		StockId:  stockId,
+		OpUserId: self.mchId,
+		NonceStr: randString(self.NonceLen),
+		Version:  "1.0",
+		Type:     "XML",
+	}
+
+	sign, err := self.signRequest(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	param.Sign = sign
+
+	data, _, err := self.post(ctx, self.nonSecureClient, QUERY_COUPONS_INFO_URL, param)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &CouponInfoResponse{}
+	if err = xml.Unmarshal(data, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
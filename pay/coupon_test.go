@@ -0,0 +1,47 @@
+package pay
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func Test_wechatPay_SendCoupon(t *testing.T) {
+	values := map[string]string{
+		"return_code":     "SUCCESS",
+		"result_code":     "SUCCESS",
+		"coupon_stock_id": "1717",
+		"openid":          "u0",
+		"ret_code":        "SUCCESS",
+		"coupon_id":       "c0",
+	}
+
+	client := &wechatPay{
+		apiSignKey: pay.apiSignKey,
+		NonceLen:   16,
+		secureClient: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewReader(signedNotifyBody(values))), Header: http.Header{}}, nil
+		})},
+	}
+
+	resp, err := client.SendCoupon("1717", "p0", "u0")
+	if err != nil {
+		t.Fatalf("SendCoupon return err: %v", err)
+	}
+	if resp.CouponId != "c0" {
+		t.Errorf("SendCoupon fail for coupon_id. get: %+v", resp)
+	}
+
+	values["ret_code"] = "FAILED"
+	values["ret_msg"] = "用户已达领取上限"
+	delete(values, "coupon_id")
+
+	resp, err = client.SendCoupon("1717", "p1", "u0")
+	if resp == nil || resp.RetCode != "FAILED" {
+		t.Errorf("SendCoupon should return response when ret_code FAILED. get: %+v", resp)
+	}
+	if e, ok := err.(*CouponSendError); !ok || e.RetCode != "FAILED" || e.RetMsg != "用户已达领取上限" {
+		t.Errorf("SendCoupon should return *CouponSendError when ret_code FAILED. get: %v", err)
+	}
+}
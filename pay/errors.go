@@ -0,0 +1,172 @@
+package pay
+
+import "fmt"
+
+// 签名校验失败，可能是伪造的请求或返回
+// 不包含本地计算得到的签名，避免错误信息被回显给调用方后用于伪造签名
+type SignError struct {
+	Sign string // 报文中携带的签名
+}
+
+func (e *SignError) Error() string {
+	return fmt.Sprintf("verify sign fail. sign: %v", e.Sign)
+}
+
+// 报文无法解析
+type FormatError struct {
+	Err error
+}
+
+func (e *FormatError) Error() string {
+	return fmt.Sprintf("malformed xml body: %v", e.Err)
+}
+
+// 请求参数未通过本地校验，请求没有发送到微信
+type ValidationError struct {
+	Field  string // 参数名，与接口文档中的字段名一致
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid param %v: %v", e.Field, e.Reason)
+}
+
+// 微信返回的错误码 err_code
+type ErrCode string
+
+const (
+	// 通用错误码
+	ERR_CODE_SYSTEMERROR           ErrCode = "SYSTEMERROR"           // 系统错误
+	ERR_CODE_PARAM_ERROR           ErrCode = "PARAM_ERROR"           // 参数错误
+	ERR_CODE_SIGNERROR             ErrCode = "SIGNERROR"             // 签名错误
+	ERR_CODE_SIGN_ERROR            ErrCode = "SIGN_ERROR"            // 签名错误，企业付款接口使用
+	ERR_CODE_NOAUTH                ErrCode = "NOAUTH"                // 商户无此接口权限
+	ERR_CODE_LACK_PARAMS           ErrCode = "LACK_PARAMS"           // 缺少参数
+	ERR_CODE_APPID_NOT_EXIST       ErrCode = "APPID_NOT_EXIST"       // APPID不存在
+	ERR_CODE_MCHID_NOT_EXIST       ErrCode = "MCHID_NOT_EXIST"       // MCHID不存在
+	ERR_CODE_APPID_MCHID_NOT_MATCH ErrCode = "APPID_MCHID_NOT_MATCH" // appid和mch_id不匹配
+	ERR_CODE_XML_FORMAT_ERROR      ErrCode = "XML_FORMAT_ERROR"      // XML格式错误
+	ERR_CODE_REQUIRE_POST_METHOD   ErrCode = "REQUIRE_POST_METHOD"   // 请使用post方法
+	ERR_CODE_POST_DATA_EMPTY       ErrCode = "POST_DATA_EMPTY"       // post数据为空
+	ERR_CODE_NOT_UTF8              ErrCode = "NOT_UTF8"              // 编码格式错误
+	ERR_CODE_FREQ_LIMIT            ErrCode = "FREQ_LIMIT"            // 接口调用频率超限
+	ERR_CODE_CA_ERROR              ErrCode = "CA_ERROR"              // 商户证书校验出错
+	ERR_CODE_INVALID_REQUEST       ErrCode = "INVALID_REQUEST"       // 参数错误
+
+	// 支付相关错误码
+	ERR_CODE_NOTENOUGH             ErrCode = "NOTENOUGH"             // 余额不足
+	ERR_CODE_ORDERPAID             ErrCode = "ORDERPAID"             // 商户订单已支付
+	ERR_CODE_ORDERCLOSED           ErrCode = "ORDERCLOSED"           // 订单已关闭
+	ERR_CODE_ORDERNOTEXIST         ErrCode = "ORDERNOTEXIST"         // 此交易订单号不存在
+	ERR_CODE_ORDERREVERSED         ErrCode = "ORDERREVERSED"         // 订单已撤销
+	ERR_CODE_OUT_TRADE_NO_USED     ErrCode = "OUT_TRADE_NO_USED"     // 商户订单号重复
+	ERR_CODE_USERPAYING            ErrCode = "USERPAYING"            // 用户支付中，需要输入密码
+	ERR_CODE_BANKERROR             ErrCode = "BANKERROR"             // 银行系统异常
+	ERR_CODE_AUTHCODEEXPIRE        ErrCode = "AUTHCODEEXPIRE"        // 付款码已过期
+	ERR_CODE_AUTH_CODE_ERROR       ErrCode = "AUTH_CODE_ERROR"       // 付款码参数错误
+	ERR_CODE_AUTH_CODE_INVALID     ErrCode = "AUTH_CODE_INVALID"     // 付款码检验错误
+	ERR_CODE_BUYER_MISMATCH        ErrCode = "BUYER_MISMATCH"        // 支付帐号错误
+	ERR_CODE_TRADE_ERROR           ErrCode = "TRADE_ERROR"           // 交易错误
+	ERR_CODE_USER_ACCOUNT_ABNORMAL ErrCode = "USER_ACCOUNT_ABNORMAL" // 退款请求失败，用户帐号已注销
+	ERR_CODE_INVALID_TRANSACTIONID ErrCode = "INVALID_TRANSACTIONID" // 无效transaction_id
+	ERR_CODE_BIZERR_NEED_RETRY     ErrCode = "BIZERR_NEED_RETRY"     // 退款业务流程错误，需要商户触发重试来解决
+	ERR_CODE_TRADE_OVERDUE         ErrCode = "TRADE_OVERDUE"         // 订单已经超过退款期限
+	ERR_CODE_ERROR                 ErrCode = "ERROR"                 // 业务错误
+	ERR_CODE_REFUNDNOTEXIST        ErrCode = "REFUNDNOTEXIST"        // 退款订单查询失败
+	ERR_CODE_FREQUENCY_LIMITED     ErrCode = "FREQUENCY_LIMITED"     // 频率限制
+	ERR_CODE_ORDER_NOT_READY       ErrCode = "ORDER_NOT_READY"       // 订单处理中，暂时无法退款
+
+	// 企业付款相关错误码
+	ERR_CODE_AMOUNT_LIMIT             ErrCode = "AMOUNT_LIMIT"             // 金额超限
+	ERR_CODE_OPENID_ERROR             ErrCode = "OPENID_ERROR"             // Openid错误
+	ERR_CODE_SEND_FAILED              ErrCode = "SEND_FAILED"              // 付款错误，请使用原单号重试
+	ERR_CODE_NAME_MISMATCH            ErrCode = "NAME_MISMATCH"            // 姓名校验出错
+	ERR_CODE_V2_ACCOUNT_SIMPLE_BAN    ErrCode = "V2_ACCOUNT_SIMPLE_BAN"    // 无法给非实名用户付款
+	ERR_CODE_SENDNUM_LIMIT            ErrCode = "SENDNUM_LIMIT"            // 该用户今日付款次数超过限制
+	ERR_CODE_MONEY_LIMIT              ErrCode = "MONEY_LIMIT"              // 已经达到今日付款总额上限
+	ERR_CODE_PAYEE_ACCOUNT_ABNORMAL   ErrCode = "PAYEE_ACCOUNT_ABNORMAL"   // 收款用户账户异常
+	ERR_CODE_PAYER_ACCOUNT_ABNORMAL   ErrCode = "PAYER_ACCOUNT_ABNORMAL"   // 商户账户付款受限
+	ERR_CODE_RECV_ACCOUNT_NOT_ALLOWED ErrCode = "RECV_ACCOUNT_NOT_ALLOWED" // 收款账户不在收款账户列表
+	ERR_CODE_PAY_CHANNEL_NOT_ALLOWED  ErrCode = "PAY_CHANNEL_NOT_ALLOWED"  // 本商户号未配置API发起能力
+	ERR_CODE_SENDAMOUNT_LIMIT         ErrCode = "SENDAMOUNT_LIMIT"         // 超过用户单日收款额度
+)
+
+// 使用原参数重试可能成功的错误码
+var retryableErrCodes = map[ErrCode]bool{
+	ERR_CODE_SYSTEMERROR:       true,
+	ERR_CODE_BANKERROR:         true,
+	ERR_CODE_FREQ_LIMIT:        true,
+	ERR_CODE_FREQUENCY_LIMITED: true,
+	ERR_CODE_BIZERR_NEED_RETRY: true,
+	ERR_CODE_SEND_FAILED:       true,
+	ERR_CODE_ORDER_NOT_READY:   true,
+}
+
+// 微信返回失败
+// return_code 不为 SUCCESS 时为通信失败，此时只有 ReturnMsg；result_code 不为 SUCCESS 时为业务失败，ErrCode 表示具体原因
+type ResultError struct {
+	ReturnCode string
+	ReturnMsg  string
+	ResultCode string
+	ErrCode    ErrCode
+	ErrCodeDes string
+}
+
+func (e *ResultError) Error() string {
+	if e.IsCommunicationError() {
+		return fmt.Sprintf("return_code: %v, return_msg: %v", e.ReturnCode, e.ReturnMsg)
+	}
+
+	return fmt.Sprintf("result_code: %v, err_code: %v, err_code_des: %v", e.ResultCode, e.ErrCode, e.ErrCodeDes)
+}
+
+// 支持 errors.Is(err, ErrOrderPaid) 等按错误码判断
+func (e *ResultError) Is(target error) bool {
+	switch target {
+	case ErrOrderPaid:
+		return e.ErrCode == ERR_CODE_ORDERPAID
+	case ErrOrderClosed:
+		return e.ErrCode == ERR_CODE_ORDERCLOSED
+	case ErrSystemError:
+		return e.ErrCode == ERR_CODE_SYSTEMERROR
+	}
+
+	return false
+}
+
+// 通信失败，通常由签名、参数格式等请求本身的问题导致
+func (e *ResultError) IsCommunicationError() bool {
+	return e.ReturnCode != "SUCCESS"
+}
+
+// 业务失败
+func (e *ResultError) IsBusinessError() bool {
+	return !e.IsCommunicationError()
+}
+
+// 是否可以使用相同的参数（包括相同的商户单号）重试
+func (e *ResultError) Retryable() bool {
+	return e.IsBusinessError() && retryableErrCodes[e.ErrCode]
+}
+
+// 根据 return_code 与 result_code 检查结果，失败时返回 *ResultError
+func checkResult(values map[string]string) error {
+	if values["return_code"] != "SUCCESS" {
+		return &ResultError{
+			ReturnCode: values["return_code"],
+			ReturnMsg:  values["return_msg"],
+		}
+	}
+
+	if result, ok := values["result_code"]; ok && result != "SUCCESS" {
+		return &ResultError{
+			ReturnCode: values["return_code"],
+			ReturnMsg:  values["return_msg"],
+			ResultCode: result,
+			ErrCode:    ErrCode(values["err_code"]),
+			ErrCodeDes: values["err_code_des"],
+		}
+	}
+
+	return nil
+}
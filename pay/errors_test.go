@@ -0,0 +1,45 @@
+package pay
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func Test_checkResult(t *testing.T) {
+	if err := checkResult(map[string]string{"return_code": "SUCCESS", "result_code": "SUCCESS"}); err != nil {
+		t.Errorf("checkResult should pass. get: %v", err)
+	}
+
+	err := checkResult(map[string]string{"return_code": "FAIL", "return_msg": "签名失败"})
+	if e, ok := err.(*ResultError); !ok || !e.IsCommunicationError() || e.Retryable() {
+		t.Errorf("checkResult should return communication error. get: %#v", err)
+	}
+
+	err = checkResult(map[string]string{"return_code": "SUCCESS", "result_code": "FAIL", "err_code": "SYSTEMERROR"})
+	if e, ok := err.(*ResultError); !ok || !e.IsBusinessError() || e.ErrCode != ERR_CODE_SYSTEMERROR || !e.Retryable() {
+		t.Errorf("checkResult should return retryable business error. get: %#v", err)
+	}
+
+	err = checkResult(map[string]string{"return_code": "SUCCESS", "result_code": "FAIL", "err_code": "NOTENOUGH"})
+	if e, ok := err.(*ResultError); !ok || e.ErrCode != ERR_CODE_NOTENOUGH || e.Retryable() {
+		t.Errorf("checkResult should return non-retryable business error. get: %#v", err)
+	}
+}
+
+func Test_ResultError_Is(t *testing.T) {
+	var err error = &ResultError{ReturnCode: "SUCCESS", ResultCode: "FAIL", ErrCode: ERR_CODE_ORDERPAID}
+	if !errors.Is(err, ErrOrderPaid) || errors.Is(err, ErrOrderClosed) {
+		t.Errorf("ResultError should match ErrOrderPaid only. get: %v", err)
+	}
+
+	var resultErr *ResultError
+	if !errors.As(fmt.Errorf("close order: %w", err), &resultErr) || resultErr.ErrCode != ERR_CODE_ORDERPAID {
+		t.Errorf("errors.As should find *ResultError")
+	}
+
+	err = &ResultError{ReturnCode: "SUCCESS", ResultCode: "FAIL", ErrCode: ERR_CODE_SYSTEMERROR}
+	if !errors.Is(err, ErrSystemError) || !err.(*ResultError).Retryable() {
+		t.Errorf("ResultError should match ErrSystemError and be retryable. get: %v", err)
+	}
+}
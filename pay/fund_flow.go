@@ -0,0 +1,189 @@
+package pay
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"time"
+)
+
+/*
+下载资金账单，需要证书，且只支持 HMAC-SHA256 签名
+https://pay.weixin.qq.com/wiki/doc/api/jsapi.php?chapter=9_18&index=7
+*/
+
+const (
+	DOWNLOAD_FUND_FLOW_URL = "https://api.mch.weixin.qq.com/pay/downloadfundflow"
+)
+
+type AccountType string
+
+const (
+	ACCOUNT_TYPE_BASIC     AccountType = "Basic"     // 基本账户
+	ACCOUNT_TYPE_OPERATION AccountType = "Operation" // 运营账户
+	ACCOUNT_TYPE_FEES      AccountType = "Fees"      // 手续费账户
+)
+
+type DownloadFundFlowParam struct {
+	AppId       string `xml:"appid"`
+	Mchid       string `xml:"mch_id"`
+	NonceStr    string `xml:"nonce_str"`
+	Sign        string `xml:"sign"`
+	SignType    string `xml:"sign_type"`    // 只支持 HMAC-SHA256
+	BillDate    string `xml:"bill_date"`    // 资金账单日期，格式：20140603
+	AccountType string `xml:"account_type"` // 资金账户类型
+	TarType     string `xml:"tar_type"`     // 固定值 GZIP，不传则返回原始数据流
+}
+
+// 资金账单中的一笔资金变动，金额单位为分
+type FundFlowRecord struct {
+	BillingTime   string // 记账时间
+	TransactionId string // 微信支付业务单号
+	FundFlowId    string // 资金流水单号
+	BizName       string // 业务名称
+	BizType       string // 业务类型
+	FinancialType string // 收支类型：收入、支出
+	Amount        int64  // 收支金额
+	Balance       int64  // 账户结余
+	ApplicantName string // 资金变更提交申请人
+	Memo          string // 备注
+	BizVoucherId  string // 业务凭证号
+
+	Fields map[string]string // 列名 -> 原始值
+}
+
+// 资金账单汇总，金额单位为分
+type FundFlowSummary struct {
+	TotalCount   int64 // 资金流水总笔数
+	IncomeCount  int64 // 收入笔数
+	IncomeAmount int64 // 收入金额
+	ExpendCount  int64 // 支出笔数
+	ExpendAmount int64 // 支出金额
+
+	Fields map[string]string // 列名 -> 原始值
+}
+
+// 流式读取资金账单，使用完毕后需要调用 Close
+type FundFlowReader struct {
+	scanner *billScanner
+	summary *FundFlowSummary
+}
+
+func (r *FundFlowReader) Next() (*FundFlowRecord, error) {
+	fields, err := r.scanner.next()
+	if err == io.EOF && r.summary == nil && r.scanner.summary != nil {
+		if r.summary, err = parseFundFlowSummary(r.scanner.summary); err == nil {
+			err = io.EOF
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return parseFundFlowRecord(fields)
+}
+
+// 资金账单汇总，Next 返回 io.EOF 之后可用，账单没有汇总数据时返回 nil
+func (r *FundFlowReader) Summary() *FundFlowSummary {
+	return r.summary
+}
+
+func (r *FundFlowReader) Close() error {
+	return r.scanner.Close()
+}
+
+// 下载资金账单，使用证书请求，并固定使用 HMAC-SHA256 签名
+// gzipped 为 true 时请求压缩后的账单，由 FundFlowReader 解压
+func (self *wechatPay) DownloadFundFlow(billDate time.Time, accountType AccountType, gzipped bool) (*FundFlowReader, error) {
+	return self.DownloadFundFlowWithContext(context.Background(), billDate, accountType, gzipped)
+}
+
+func (self *wechatPay) DownloadFundFlowWithContext(ctx context.Context, billDate time.Time, accountType AccountType, gzipped bool) (*FundFlowReader, error) {
+	param := &DownloadFundFlowParam{
+		AppId:       self.AppId,
+		Mchid:       self.mchId,
+		NonceStr:    randString(self.NonceLen),
+		SignType:    string(SIGN_TYPE_HMAC_SHA256),
+		BillDate:    billDate.Format("20060102"),
+		AccountType: string(accountType),
+	}
+
+	if gzipped {
+		param.TarType = "GZIP"
+	}
+
+	sign, err := self.signRequest(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	param.Sign = sign
+
+	result, err := self.doRequest(ctx, self.secureClient, DOWNLOAD_FUND_FLOW_URL, param)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner, err := newBillScanner(result.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FundFlowReader{scanner: scanner}, nil
+}
+
+func parseFundFlowRecord(fields map[string]string) (*FundFlowRecord, error) {
+	record := &FundFlowRecord{
+		BillingTime:   fields["记账时间"],
+		TransactionId: fields["微信支付业务单号"],
+		FundFlowId:    fields["资金流水单号"],
+		BizName:       fields["业务名称"],
+		BizType:       fields["业务类型"],
+		FinancialType: fields["收支类型"],
+		ApplicantName: fields["资金变更提交申请人"],
+		Memo:          fields["备注"],
+		BizVoucherId:  fields["业务凭证号"],
+		Fields:        fields,
+	}
+
+	amounts := map[string]*int64{
+		"收支金额（元）": &record.Amount,
+		"账户结余（元）": &record.Balance,
+	}
+	if err := parseBillAmounts(fields, amounts); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+func parseFundFlowSummary(fields map[string]string) (*FundFlowSummary, error) {
+	summary := &FundFlowSummary{
+		Fields: fields,
+	}
+
+	counts := map[string]*int64{
+		"资金流水总笔数": &summary.TotalCount,
+		"收入笔数":    &summary.IncomeCount,
+		"支出笔数":    &summary.ExpendCount,
+	}
+	for name, count := range counts {
+		if value := fields[name]; value != "" {
+			result, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, &FormatError{Err: err}
+			}
+			*count = result
+		}
+	}
+
+	amounts := map[string]*int64{
+		"收入金额": &summary.IncomeAmount,
+		"支出金额": &summary.ExpendAmount,
+	}
+	if err := parseBillAmounts(fields, amounts); err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,41 @@
+package pay
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+const testFundFlow = "记账时间,微信支付业务单号,资金流水单号,业务名称,业务类型,收支类型,收支金额（元）,账户结余（元）,资金变更提交申请人,备注,业务凭证号\r\n" +
+	"`2018-02-01 04:21:23,`50000305742018020103387128253,`1900009231201802015884652186,`退款,`退款,`支出,`0.02,`0.17,`system,`缺货,`REF4200000068201801293084726067\r\n" +
+	"资金流水总笔数,收入笔数,收入金额,支出笔数,支出金额\r\n" +
+	"`1,`0,`0.00,`1,`0.02\r\n"
+
+func Test_FundFlowReader(t *testing.T) {
+	scanner, err := newBillScanner(ioutil.NopCloser(bytes.NewReader([]byte(testFundFlow))))
+	if err != nil {
+		t.Fatalf("newBillScanner return err: %v", err)
+	}
+
+	reader := &FundFlowReader{scanner: scanner}
+	defer reader.Close()
+
+	record, err := reader.Next()
+	if err != nil {
+		t.Fatalf("Next return err: %v", err)
+	}
+
+	if record.FundFlowId != "1900009231201802015884652186" || record.FinancialType != "支出" || record.Amount != 2 || record.Balance != 17 || record.Memo != "缺货" {
+		t.Errorf("FundFlowReader fail for record. get: %+v", record)
+	}
+
+	if _, err := reader.Next(); err != io.EOF {
+		t.Fatalf("Next should return io.EOF. get: %v", err)
+	}
+
+	summary := reader.Summary()
+	if summary == nil || summary.TotalCount != 1 || summary.ExpendCount != 1 || summary.ExpendAmount != 2 {
+		t.Errorf("FundFlowReader fail for summary. get: %+v", summary)
+	}
+}
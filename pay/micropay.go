@@ -0,0 +1,211 @@
+package pay
+
+import (
+	"context"
+	"encoding/xml"
+	"time"
+)
+
+/*
+付款码支付
+https://pay.weixin.qq.com/wiki/doc/api/micropay.php?chapter=9_10&index=1
+*/
+
+const (
+	MICROPAY_URL = "https://api.mch.weixin.qq.com/pay/micropay"
+
+	DEFAULT_MICROPAY_POLL_INTERVAL = 5 * time.Second  // 默认查询间隔
+	DEFAULT_MICROPAY_TIMEOUT       = 30 * time.Second // 默认等待用户支付的时间，超时后撤销订单
+)
+
+type MicropayStatus string
+
+const (
+	MICROPAY_STATUS_SUCCESS  MicropayStatus = "SUCCESS"  // 支付成功
+	MICROPAY_STATUS_FAILED   MicropayStatus = "FAILED"   // 支付失败，用户未被扣款
+	MICROPAY_STATUS_REVERSED MicropayStatus = "REVERSED" // 等待超时，订单已撤销
+	MICROPAY_STATUS_UNKNOWN  MicropayStatus = "UNKNOWN"  // 支付结果未知且未能撤销，需要稍后查询订单或再次撤销
+)
+
+type MicropayParam struct {
+	AppId          string `xml:"appid"`
+	Mchid          string `xml:"mch_id"`
+	DeviceInfo     string `xml:"device_info"`
+	NonceStr       string `xml:"nonce_str"`
+	Sign           string `xml:"sign"`
+	SignType       string `xml:"sign_type"`
+	Body           string `xml:"body"`
+	Attach         string `xml:"attach"`
+	OutTradeNo     string `xml:"out_trade_no"`
+	TotalFee       int64  `xml:"total_fee"`
+	SPBillCreateIP string `xml:"spbill_create_ip"` // 调用微信支付API的机器IP，必填
+	GoodsTag       string `xml:"goods_tag"`
+	AuthCode       string `xml:"auth_code"` // 付款码，扫码设备读取用户微信中的条码或者二维码信息
+}
+
+type MicropayResponse struct {
+	ReturnCode string `xml:"return_code"`
+	ReturnMsg  string `xml:"return_msg"`
+	AppId      string `xml:"appid"`
+	MchId      string `xml:"mch_id"`
+	DeviceInfo string `xml:"device_info"`
+	NonceStr   string `xml:"nonce_str"`
+	Sign       string `xml:"sign"`
+	ResultCode string `xml:"result_code"`
+	ErrCode    string `xml:"err_code"`
+	ErrCodeDes string `xml:"err_code_des"`
+
+	Openid             string `xml:"openid"`
+	IsSubscribe        string `xml:"is_subscribe"`
+	TradeType          string `xml:"trade_type"` // MICROPAY
+	BankType           string `xml:"bank_type"`
+	FeeType            string `xml:"fee_type"`
+	TotalFee           int64  `xml:"total_fee"`
+	SettlementTotalFee int64  `xml:"settlement_total_fee"`
+	CouponFee          int64  `xml:"coupon_fee"`
+	CashFeeType        string `xml:"cash_fee_type"`
+	CashFee            int64  `xml:"cash_fee"`
+	TransactionId      string `xml:"transaction_id"`
+	OutTradeNo         string `xml:"out_trade_no"`
+	Attach             string `xml:"attach"`
+	TimeEnd            string `xml:"time_end"`
+}
+
+// 付款码支付的等待策略
+type MicropayOptions struct {
+	PollInterval time.Duration // 查询订单的间隔，为 0 时使用 DEFAULT_MICROPAY_POLL_INTERVAL
+	Timeout      time.Duration // 等待用户支付的最长时间，超时后撤销订单，为 0 时使用 DEFAULT_MICROPAY_TIMEOUT
+	SignType     SignType      // 本次支付及查询、撤销订单的签名算法，为空时使用创建 WechatPay 时的签名算法
+}
+
+// 付款码支付的最终结果
+type MicropayResult struct {
+	Status MicropayStatus
+
+	Response *MicropayResponse   // 付款码支付接口直接返回成功时的结果
+	Order    *OrderQueryResponse // 经过查询确认支付结果时的订单信息
+	Reverse  *ReverseResult      // 等待超时后撤销订单的结果
+	Reason   error               // 支付失败或结果未知的原因，通常为 *ResultError
+}
+
+// 付款码支付
+// 微信返回 USERPAYING、SYSTEMERROR、BANKERROR、return_code 不为 SUCCESS、返回验签失败或请求失败时，按 options 查询订单直到得到明确结果，等待超时后撤销订单
+// 无法确定订单状态时（如撤销失败、ctx 被取消）同时返回状态为 MICROPAY_STATUS_UNKNOWN 的结果与 error，此时需要调用方查询或撤销订单
+func (self *wechatPay) Micropay(authCode, body, attach, outTradeNo string, totalFee int64, ip string, options *MicropayOptions) (*MicropayResult, error) {
+	return self.MicropayWithContext(context.Background(), authCode, body, attach, outTradeNo, totalFee, ip, options)
+}
+
+func (self *wechatPay) MicropayWithContext(ctx context.Context, authCode, body, attach, outTradeNo string, totalFee int64, ip string, options *MicropayOptions) (*MicropayResult, error) {
+	param := &MicropayParam{
+		AppId:          self.AppId,
+		Mchid:          self.mchId,
+		NonceStr:       randString(self.NonceLen),
+		SignType:       string(options.signType(self.signType)),
+		Body:           body,
+		Attach:         attach,
+		OutTradeNo:     outTradeNo,
+		TotalFee:       totalFee,
+		SPBillCreateIP: ip,
+		AuthCode:       authCode,
+	}
+
+	sign, err := self.signRequest(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	param.Sign = sign
+
+	data, _, err := self.post(ctx, self.nonSecureClient, MICROPAY_URL, param)
+	if err == nil {
+		resp := &MicropayResponse{}
+		if err = xml.Unmarshal(data, resp); err != nil {
+			return nil, err
+		}
+
+		return &MicropayResult{Status: MICROPAY_STATUS_SUCCESS, Response: resp}, nil
+	}
+
+	if e, ok := err.(*ResultError); ok && !needMicropayQuery(e) {
+		return &MicropayResult{Status: MICROPAY_STATUS_FAILED, Reason: e}, nil
+	}
+
+	return self.waitMicropay(ctx, outTradeNo, options)
+}
+
+// 本次支付的签名算法，未指定时使用 defaultSignType
+func (options *MicropayOptions) signType(defaultSignType SignType) SignType {
+	if options == nil || options.SignType == "" {
+		return defaultSignType
+	}
+
+	return options.SignType
+}
+
+// 用户支付中或结果未知时需要查询订单，其余业务错误为明确的支付失败
+// return_code 不为 SUCCESS 时无法确认微信是否已处理该笔支付，同样需要查询订单
+func needMicropayQuery(e *ResultError) bool {
+	if e.IsCommunicationError() {
+		return true
+	}
+
+	switch e.ErrCode {
+	case ERR_CODE_USERPAYING, ERR_CODE_SYSTEMERROR, ERR_CODE_BANKERROR:
+		return true
+	}
+
+	return false
+}
+
+// 轮询订单状态，超时后撤销订单
+func (self *wechatPay) waitMicropay(ctx context.Context, outTradeNo string, options *MicropayOptions) (*MicropayResult, error) {
+	interval, timeout := DEFAULT_MICROPAY_POLL_INTERVAL, DEFAULT_MICROPAY_TIMEOUT
+	if options != nil && options.PollInterval > 0 {
+		interval = options.PollInterval
+	}
+	if options != nil && options.Timeout > 0 {
+		timeout = options.Timeout
+	}
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		// 最后一次等待不超过剩余时间，避免超出等待策略
+		wait := interval
+		if left := time.Until(deadline); left < wait {
+			wait = left
+		}
+
+		select {
+		case <-ctx.Done():
+			return &MicropayResult{Status: MICROPAY_STATUS_UNKNOWN, Reason: ctx.Err()}, ctx.Err()
+		case <-time.After(wait):
+		}
+
+		order, err := self.OrderQueryWithContext(ctx, "", outTradeNo, WithRequestSignType(options.signType(self.signType)))
+		if err != nil {
+			// 查询失败时继续等待，超时后通过撤销得到确定结果
+			continue
+		}
+
+		switch order.TradeState {
+		case TRADE_STATE_SUCCESS:
+			return &MicropayResult{Status: MICROPAY_STATUS_SUCCESS, Order: order}, nil
+		case TRADE_STATE_REVOKED:
+			return &MicropayResult{Status: MICROPAY_STATUS_REVERSED, Order: order}, nil
+		case TRADE_STATE_PAYERROR, TRADE_STATE_CLOSED:
+			return &MicropayResult{Status: MICROPAY_STATUS_FAILED, Order: order}, nil
+		}
+	}
+
+	// 撤销失败时支付结果未知，返回 MICROPAY_STATUS_UNKNOWN 的结果与错误，调用方需要稍后查询订单或再次撤销
+	result, err := self.ReverseWithContext(ctx, "", outTradeNo, WithRequestSignType(options.signType(self.signType)))
+	if err != nil {
+		return &MicropayResult{Status: MICROPAY_STATUS_UNKNOWN, Reason: err}, err
+	}
+
+	if result.Status != REVERSE_STATUS_REVERSED {
+		return &MicropayResult{Status: MICROPAY_STATUS_UNKNOWN, Reverse: result, Reason: result.Reason}, result.Reason
+	}
+
+	return &MicropayResult{Status: MICROPAY_STATUS_REVERSED, Reverse: result}, nil
+}
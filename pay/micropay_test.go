@@ -0,0 +1,109 @@
+package pay
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_wechatPay_waitMicropay_reverseFail(t *testing.T) {
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		var body []byte
+		if strings.HasSuffix(r.URL.Path, "/orderquery") {
+			body = signedNotifyBody(map[string]string{
+				"return_code": "SUCCESS",
+				"result_code": "SUCCESS",
+				"trade_state": string(TRADE_STATE_USERPAYING),
+			})
+		} else {
+			body = signedNotifyBody(map[string]string{
+				"return_code": "SUCCESS",
+				"result_code": "FAIL",
+				"err_code":    "REVERSE_EXPIRE",
+				"recall":      "N",
+			})
+		}
+		return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewReader(body)), Header: http.Header{}}, nil
+	})
+
+	client := &wechatPay{
+		apiSignKey:      pay.apiSignKey,
+		NonceLen:        16,
+		signType:        SIGN_TYPE_MD5,
+		secureClient:    &http.Client{Transport: transport},
+		nonSecureClient: &http.Client{Transport: transport},
+	}
+
+	result, err := client.waitMicropay(context.Background(), "o0", &MicropayOptions{PollInterval: time.Millisecond, Timeout: 5 * time.Millisecond})
+	if err == nil {
+		t.Errorf("waitMicropay should return err when reverse fail")
+	}
+	if result == nil || result.Status != MICROPAY_STATUS_UNKNOWN || result.Reverse == nil || result.Reverse.Status != REVERSE_STATUS_FAILED {
+		t.Errorf("waitMicropay should return UNKNOWN result with reverse outcome. get: %+v", result)
+	}
+}
+
+func Test_wechatPay_Micropay_returnFail(t *testing.T) {
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		var body []byte
+		if strings.HasSuffix(r.URL.Path, "/orderquery") {
+			body = signedNotifyBody(map[string]string{
+				"return_code": "SUCCESS",
+				"result_code": "SUCCESS",
+				"trade_state": string(TRADE_STATE_SUCCESS),
+			})
+		} else {
+			body = []byte("<xml><return_code>FAIL</return_code><return_msg>系统繁忙</return_msg></xml>")
+		}
+		return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewReader(body)), Header: http.Header{}}, nil
+	})
+
+	client := &wechatPay{
+		apiSignKey:      pay.apiSignKey,
+		NonceLen:        16,
+		signType:        SIGN_TYPE_MD5,
+		nonSecureClient: &http.Client{Transport: transport},
+	}
+
+	result, err := client.Micropay("a0", "b0", "", "o0", 1, "127.0.0.1", &MicropayOptions{PollInterval: time.Millisecond, Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("Micropay return err: %v", err)
+	}
+	if result.Status != MICROPAY_STATUS_SUCCESS || result.Order == nil {
+		t.Errorf("Micropay should confirm by order query when return_code FAIL. get: %+v", result)
+	}
+}
+
+func Test_wechatPay_waitMicropay_deadline(t *testing.T) {
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body := signedNotifyBody(map[string]string{
+			"return_code": "SUCCESS",
+			"result_code": "SUCCESS",
+			"recall":      "N",
+		})
+		return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewReader(body)), Header: http.Header{}}, nil
+	})
+
+	client := &wechatPay{
+		apiSignKey:   pay.apiSignKey,
+		NonceLen:     16,
+		signType:     SIGN_TYPE_MD5,
+		secureClient: &http.Client{Transport: transport},
+	}
+
+	start := time.Now()
+	result, err := client.waitMicropay(context.Background(), "o0", &MicropayOptions{PollInterval: time.Hour, Timeout: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("waitMicropay return err: %v", err)
+	}
+	if result.Status != MICROPAY_STATUS_REVERSED {
+		t.Errorf("waitMicropay should reverse after timeout. get: %+v", result)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("waitMicropay should not wait past timeout. elapsed: %v", elapsed)
+	}
+}
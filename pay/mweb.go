@@ -0,0 +1,68 @@
+package pay
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+/*
+H5 支付，用于微信外的手机浏览器，统一下单时 trade_type 为 MWEB，并需要在 UnifiedOrderRequest 中传入 SceneInfo 与用户的真实 ip
+https://pay.weixin.qq.com/wiki/doc/api/H5.php?chapter=9_20&index=1
+统一下单返回的 mweb_url 有效期为 5 分钟，可以附加 redirect_url 指定支付完成后返回的页面
+*/
+
+// H5 支付的场景类型
+type H5SceneType string
+
+const (
+	H5_SCENE_TYPE_IOS     H5SceneType = "IOS"
+	H5_SCENE_TYPE_ANDROID H5SceneType = "Android"
+	H5_SCENE_TYPE_WAP     H5SceneType = "Wap"
+)
+
+// H5 支付的场景信息，IOS 需要 app_name 与 bundle_id，Android 需要 app_name 与 package_name，Wap 需要 wap_url 与 wap_name
+type H5Info struct {
+	Type        H5SceneType `json:"type"`
+	AppName     string      `json:"app_name,omitempty"`
+	BundleId    string      `json:"bundle_id,omitempty"`
+	PackageName string      `json:"package_name,omitempty"`
+	WapUrl      string      `json:"wap_url,omitempty"`
+	WapName     string      `json:"wap_name,omitempty"`
+}
+
+// 实际门店信息
+type StoreInfo struct {
+	Id       string `json:"id"`
+	Name     string `json:"name,omitempty"`
+	AreaCode string `json:"area_code,omitempty"` // 门店所在地的行政区划码
+	Address  string `json:"address,omitempty"`
+}
+
+// 统一下单的场景信息，H5 支付时 H5Info 必填
+type SceneInfo struct {
+	StoreInfo *StoreInfo `json:"store_info,omitempty"`
+	H5Info    *H5Info    `json:"h5_info,omitempty"`
+}
+
+// 编码为 scene_info 字段要求的 json
+func (info *SceneInfo) Encode() string {
+	// 只包含字符串字段，编码不会失败
+	data, _ := json.Marshal(info)
+	return string(data)
+}
+
+// 在 mweb_url 后附加 redirect_url，用户支付完成后返回该页面，redirectUrl 的域名需要与商户后台登记的 H5 支付域名一致
+// 返回该页面不代表支付成功，需要查询订单或等待支付结果通知
+func (resp *UnifiedOrderResponse) MwebRedirectUrl(redirectUrl string) string {
+	if resp.MwebUrl == "" || redirectUrl == "" {
+		return resp.MwebUrl
+	}
+
+	sep := "&"
+	if !strings.Contains(resp.MwebUrl, "?") {
+		sep = "?"
+	}
+
+	return resp.MwebUrl + sep + "redirect_url=" + url.QueryEscape(redirectUrl)
+}
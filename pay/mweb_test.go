@@ -0,0 +1,38 @@
+package pay
+
+import (
+	"testing"
+)
+
+func Test_SceneInfo_Encode(t *testing.T) {
+	info := &SceneInfo{
+		H5Info: &H5Info{
+			Type:    H5_SCENE_TYPE_WAP,
+			WapUrl:  "https://pay.qq.com",
+			WapName: "腾讯充值",
+		},
+	}
+
+	want := `{"h5_info":{"type":"Wap","wap_url":"https://pay.qq.com","wap_name":"腾讯充值"}}`
+	if result := info.Encode(); result != want {
+		t.Errorf("Encode fail. want: %v. get: %v", want, result)
+	}
+
+	param := pay.newUnifiedOrderParam(&UnifiedOrderRequest{SceneInfo: info})
+	if param.SceneInfo != want {
+		t.Errorf("newUnifiedOrderParam fail for scene_info. want: %v. get: %v", want, param.SceneInfo)
+	}
+}
+
+func Test_UnifiedOrderResponse_MwebRedirectUrl(t *testing.T) {
+	resp := &UnifiedOrderResponse{MwebUrl: "https://wx.tenpay.com/cgi-bin/mmpayweb-bin/checkmweb?prepay_id=wx0&package=1037687096"}
+
+	want := resp.MwebUrl + "&redirect_url=https%3A%2F%2Fwww.example.com%2Fpaid%3Forder%3D1"
+	if result := resp.MwebRedirectUrl("https://www.example.com/paid?order=1"); result != want {
+		t.Errorf("MwebRedirectUrl fail. want: %v. get: %v", want, result)
+	}
+
+	if result := resp.MwebRedirectUrl(""); result != resp.MwebUrl {
+		t.Errorf("MwebRedirectUrl should keep mweb_url without redirect_url. get: %v", result)
+	}
+}
@@ -0,0 +1,217 @@
+package pay
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+/*
+扫码支付模式一，商户为商品生成固定的二维码，用户扫码后微信回调商户，由商户统一下单并返回 prepay_id
+https://pay.weixin.qq.com/wiki/doc/api/native.php?chapter=6_4
+模式二直接使用统一下单返回的 code_url 生成二维码
+*/
+
+const (
+	NATIVE_BIZ_PAY_URL = "weixin://wxpay/bizpayurl"
+
+	NATIVE_PREPAY_FAIL_MSG = "下单失败，请稍后再试" // 下单失败时展示给用户的 err_code_des
+)
+
+// 生成二维码链接时参与签名的参数
+type nativeBizPayParam struct {
+	AppId     string `xml:"appid"`
+	Mchid     string `xml:"mch_id"`
+	TimeStamp string `xml:"time_stamp"`
+	NonceStr  string `xml:"nonce_str"`
+	ProductId string `xml:"product_id"`
+	Sign      string `xml:"sign"`
+}
+
+// 用户扫码后微信发起的回调
+type NativeCallbackInfo struct {
+	AppId       string `xml:"appid"`
+	Openid      string `xml:"openid"`
+	MchId       string `xml:"mch_id"`
+	IsSubscribe string `xml:"is_subscribe"`
+	NonceStr    string `xml:"nonce_str"`
+	ProductId   string `xml:"product_id"`
+	Sign        string `xml:"sign"`
+}
+
+// 回复微信的内容，return_code 为 SUCCESS 时需要签名
+type nativeCallbackReply struct {
+	ReturnCode string `xml:"return_code"`
+	ReturnMsg  string `xml:"return_msg"`
+	AppId      string `xml:"appid"`
+	MchId      string `xml:"mch_id"`
+	NonceStr   string `xml:"nonce_str"`
+	PrepayId   string `xml:"prepay_id"`
+	ResultCode string `xml:"result_code"`
+	ErrCodeDes string `xml:"err_code_des"`
+	Sign       string `xml:"sign"`
+}
+
+// 生成模式一的二维码链接，商户需要在后台设置扫码回调链接
+func (self *wechatPay) NativeBizPayUrl(productId string) (string, error) {
+	param := &nativeBizPayParam{
+		AppId:     self.AppId,
+		Mchid:     self.mchId,
+		TimeStamp: strconv.FormatInt(time.Now().Unix(), 10),
+		NonceStr:  randString(self.NonceLen),
+		ProductId: productId,
+	}
+
+	sign, err := self.signWithType(param, SIGN_TYPE_MD5)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("sign", sign)
+	query.Set("appid", param.AppId)
+	query.Set("mch_id", param.Mchid)
+	query.Set("product_id", param.ProductId)
+	query.Set("time_stamp", param.TimeStamp)
+	query.Set("nonce_str", param.NonceStr)
+
+	return NATIVE_BIZ_PAY_URL + "?" + query.Encode(), nil
+}
+
+// 解析模式一的扫码回调，并对回调中出现的所有字段验签
+// 报文无法解析时返回 *FormatError，验签失败时返回 *SignError
+func (self *wechatPay) ParseNativeCallback(body []byte) (*NativeCallbackInfo, error) {
+	info := &NativeCallbackInfo{}
+
+	if err := xml.Unmarshal(body, info); err != nil {
+		return nil, &FormatError{Err: err}
+	}
+
+	values, err := parseXMLMap(body)
+	if err != nil {
+		return nil, &FormatError{Err: err}
+	}
+
+	if err := self.verifyMapSign(values, SIGN_TYPE_MD5); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// 模式一扫码回调的 http.Handler
+// 验签后调用 handle 获取 product_id 对应的订单，以 NATIVE 方式统一下单，并将 prepay_id 签名后回复微信
+// handle 返回的订单中 TradeType、ProductId、Openid 由回调填充，SPBillCreateIP 为空时使用接收回调的本机 ip
+// handle 或统一下单失败时回复 result_code 为 FAIL，err_code_des 使用固定的 NATIVE_PREPAY_FAIL_MSG 展示给用户
+type NativeHandler struct {
+	MaxBodySize int64 // 回调报文最大长度，为 0 时使用 DEFAULT_NOTIFY_MAX_BODY_SIZE
+
+	pay    WechatPay
+	handle func(info *NativeCallbackInfo) (*UnifiedOrderRequest, error)
+}
+
+func NewNativeHandler(pay WechatPay, handle func(info *NativeCallbackInfo) (*UnifiedOrderRequest, error)) *NativeHandler {
+	return &NativeHandler{
+		pay:    pay,
+		handle: handle,
+	}
+}
+
+func (h *NativeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	maxBodySize := h.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = DEFAULT_NOTIFY_MAX_BODY_SIZE
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		writeNotifyReply(w, http.StatusBadRequest, "FAIL", NOTIFY_INVALID_REQUEST_MSG)
+		return
+	}
+
+	if err := h.pay.LoadSandboxSignKey(r.Context()); err != nil {
+		writeNotifyReply(w, http.StatusOK, "FAIL", NOTIFY_HANDLE_FAIL_MSG)
+		return
+	}
+
+	info, err := h.pay.ParseNativeCallback(body)
+	if err != nil {
+		writeNotifyReply(w, http.StatusOK, "FAIL", NOTIFY_INVALID_REQUEST_MSG)
+		return
+	}
+
+	reply := &nativeCallbackReply{
+		ReturnCode: "SUCCESS",
+		ReturnMsg:  "OK",
+		AppId:      info.AppId,
+		MchId:      info.MchId,
+		NonceStr:   h.pay.GetNonceStr(),
+		ResultCode: "SUCCESS",
+	}
+
+	if prepayId, err := h.prepay(r, info); err != nil {
+		reply.ResultCode = "FAIL"
+		reply.ErrCodeDes = NATIVE_PREPAY_FAIL_MSG
+	} else {
+		reply.PrepayId = prepayId
+	}
+
+	sign, err := h.pay.Sign(reply)
+	if err != nil {
+		writeNotifyReply(w, http.StatusOK, "FAIL", NOTIFY_HANDLE_FAIL_MSG)
+		return
+	}
+
+	reply.Sign = sign
+
+	var buf bytes.Buffer
+	if err := xml.NewEncoder(&buf).EncodeElement(reply, xml.StartElement{Name: xml.Name{Local: "xml"}}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+func (h *NativeHandler) prepay(r *http.Request, info *NativeCallbackInfo) (string, error) {
+	request, err := h.handle(info)
+	if err != nil {
+		return "", err
+	}
+
+	request.TradeType = TRADE_TYPE_NATIVE
+	request.ProductId = info.ProductId
+	request.Openid = info.Openid
+	if request.SPBillCreateIP == "" {
+		request.SPBillCreateIP = localIP(r)
+	}
+
+	resp, err := h.pay.UnifiedOrderByRequestWithContext(r.Context(), request)
+	if err != nil {
+		return "", err
+	}
+
+	return resp.PrePayId, nil
+}
+
+// 接收请求的本机 ip，扫码支付的 spbill_create_ip 为调用微信支付 API 的机器 ip
+func localIP(r *http.Request) string {
+	addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	if !ok {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return ""
+	}
+
+	return host
+}
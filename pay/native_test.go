@@ -0,0 +1,98 @@
+package pay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type nativeTestPay struct {
+	*wechatPay
+	request *UnifiedOrderRequest
+}
+
+func (p *nativeTestPay) UnifiedOrderByRequestWithContext(ctx context.Context, request *UnifiedOrderRequest) (*UnifiedOrderResponse, error) {
+	p.request = request
+
+	return &UnifiedOrderResponse{TradeType: string(request.TradeType), PrePayId: "prepay-" + request.OutTradeNo}, nil
+}
+
+func Test_wechatPay_NativeBizPayUrl(t *testing.T) {
+	client := &wechatPay{AppId: "wx0", mchId: "10000100", apiSignKey: "test-Sign-key", NonceLen: 16}
+
+	result, err := client.NativeBizPayUrl("p0")
+	if err != nil {
+		t.Fatalf("NativeBizPayUrl return err: %v", err)
+	}
+
+	if !strings.HasPrefix(result, NATIVE_BIZ_PAY_URL+"?") {
+		t.Fatalf("NativeBizPayUrl fail for prefix. get: %v", result)
+	}
+
+	query, _ := url.ParseQuery(strings.TrimPrefix(result, NATIVE_BIZ_PAY_URL+"?"))
+	values := map[string]string{}
+	for name := range query {
+		values[name] = query.Get(name)
+	}
+
+	if values["product_id"] != "p0" {
+		t.Errorf("NativeBizPayUrl fail for product_id. get: %v", result)
+	}
+	if err := client.verifyMapSign(values, SIGN_TYPE_MD5); err != nil {
+		t.Errorf("NativeBizPayUrl fail for sign. err: %v", err)
+	}
+}
+
+func Test_NativeHandler(t *testing.T) {
+	testPay := &nativeTestPay{wechatPay: &wechatPay{apiSignKey: "test-Sign-key", NonceLen: 16}}
+
+	handler := NewNativeHandler(testPay, func(info *NativeCallbackInfo) (*UnifiedOrderRequest, error) {
+		if info.ProductId == "sold-out" {
+			return nil, errors.New("db down")
+		}
+		return &UnifiedOrderRequest{OutTradeNo: "o-" + info.ProductId, TotalFee: 1, SPBillCreateIP: "10.0.0.1"}, nil
+	})
+
+	serve := func(body []byte) map[string]string {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/native", bytes.NewReader(body)))
+
+		values, err := parseXMLMap(recorder.Body.Bytes())
+		if err != nil {
+			t.Fatalf("parse reply return err: %v", err)
+		}
+		return values
+	}
+
+	body := signedNotifyBody(map[string]string{
+		"appid":      "wx0",
+		"mch_id":     "10000100",
+		"openid":     "u0",
+		"product_id": "p0",
+	})
+
+	reply := serve(body)
+	if reply["return_code"] != "SUCCESS" || reply["result_code"] != "SUCCESS" || reply["prepay_id"] != "prepay-o-p0" {
+		t.Errorf("NativeHandler fail for reply. get: %v", reply)
+	}
+	if err := pay.verifyMapSign(reply, SIGN_TYPE_MD5); err != nil {
+		t.Errorf("NativeHandler reply should be signed. err: %v", err)
+	}
+	if request := testPay.request; request.ProductId != "p0" || request.Openid != "u0" || request.TradeType != TRADE_TYPE_NATIVE || request.SPBillCreateIP != "10.0.0.1" {
+		t.Errorf("NativeHandler should pass callback fields to UnifiedOrder. get: %+v", request)
+	}
+
+	reply = serve(signedNotifyBody(map[string]string{"appid": "wx0", "product_id": "sold-out"}))
+	if reply["return_code"] != "SUCCESS" || reply["result_code"] != "FAIL" || reply["err_code_des"] != NATIVE_PREPAY_FAIL_MSG {
+		t.Errorf("NativeHandler fail for handle error. get: %v", reply)
+	}
+
+	forged := bytes.Replace(body, []byte("p0"), []byte("p1"), 1)
+	if reply := serve(forged); reply["return_code"] != "FAIL" || reply["return_msg"] != NOTIFY_INVALID_REQUEST_MSG {
+		t.Errorf("NativeHandler should reply FAIL for forged body. get: %v", reply)
+	}
+}
@@ -2,14 +2,20 @@ package pay
 
 import "encoding/xml"
 
+/*
+支付结果通知
+https://pay.weixin.qq.com/wiki/doc/api/jsapi.php?chapter=9_7&index=8
+*/
+
 type NotifyInfo struct {
 	ReturnCode string `xml:"return_code"`
 	ReturnMsg  string `xml:"return_msg"`
-	AppId      string `xml:"mch_appid"`
-	MchId      string `xml:"mchid"`
+	AppId      string `xml:"appid"`
+	MchId      string `xml:"mch_id"`
 	DeviceInfo string `xml:"device_info"`
 	NonceStr   string `xml:"nonce_str"`
 	Sign       string `xml:"sign"`
+	SignType   string `xml:"sign_type"`
 
 	ResultCode string `xml:"result_code"`
 	ErrCode    string `xml:"err_code"`
@@ -29,23 +35,48 @@ type NotifyInfo struct {
 	FeeType            string `xml:"fee_type"`             //货币类型，符合ISO4217标准的三位字母代码，默认人民币：CNY
 	CashFee            int    `xml:"cash_fee"`             //现金支付金额订单现金支付金额
 	CashFeeType        string `xml:"cash_fee_type"`
+	CouponFee          int64  `xml:"coupon_fee"`   // 代金券金额
+	CouponCount        int    `xml:"coupon_count"` // 代金券使用数量
+
+	Coupons []Coupon `xml:"-"` // 由 coupon_type_$n、coupon_id_$n、coupon_fee_$n 解析得到
 }
 
 type NotifyReply struct {
-	ReturnCode string `xml:"return_code"`
-	ReturnMsg  string `xml:"return_msg"`
+	XMLName    xml.Name `xml:"xml"`
+	ReturnCode string   `xml:"return_code"`
+	ReturnMsg  string   `xml:"return_msg"`
 }
 
+// 解析支付结果通知，并对通知中出现的所有字段验签
+// 报文无法解析时返回 *FormatError，验签失败时返回 *SignError，return_code 或 result_code 失败时返回 *ResultError
 func (self *wechatPay) ParseNotifyInfo(body []byte) (*NotifyInfo, error) {
 	info := &NotifyInfo{}
 
 	if err := xml.Unmarshal(body, info); err != nil {
+		return nil, &FormatError{Err: err}
+	}
+
+	values, err := parseXMLMap(body)
+	if err != nil {
+		return nil, &FormatError{Err: err}
+	}
+
+	// return_code 为 FAIL 时通知中没有签名
+	if info.ReturnCode != "SUCCESS" {
+		return nil, checkResult(values)
+	}
+
+	if err := self.verifyMapSign(values, self.signType); err != nil {
 		return nil, err
 	}
 
-	//if err := self.VerifySign(info, info.Sign); err != nil {
-	//	return nil, err
-	//}
+	if err := checkResult(values); err != nil {
+		return nil, err
+	}
+
+	if info.Coupons, err = parseCoupons(values, info.CouponCount); err != nil {
+		return nil, &FormatError{Err: err}
+	}
 
 	return info, nil
 }
@@ -0,0 +1,119 @@
+package pay
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+)
+
+const (
+	DEFAULT_NOTIFY_MAX_BODY_SIZE = 64 << 10 // 通知报文默认最大长度
+
+	NOTIFY_INVALID_REQUEST_MSG = "invalid request" // 通知无法读取、解析或验签失败时回复的 return_msg
+	NOTIFY_HANDLE_FAIL_MSG     = "FAIL"            // 处理通知失败时回复的 return_msg
+)
+
+// 支付结果通知的 http.Handler
+// 读取通知并验签后调用 handle，handle 返回 nil 时回复 SUCCESS，否则回复 FAIL，微信会在稍后重新通知
+type NotifyHandler struct {
+	MaxBodySize int64 // 通知报文最大长度，为 0 时使用 DEFAULT_NOTIFY_MAX_BODY_SIZE
+
+	pay    WechatPay
+	handle func(info *NotifyInfo) error
+}
+
+func NewNotifyHandler(pay WechatPay, handle func(info *NotifyInfo) error) *NotifyHandler {
+	return &NotifyHandler{
+		pay:    pay,
+		handle: handle,
+	}
+}
+
+func (h *NotifyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var info *NotifyInfo
+	serveNotify(w, r, h.pay, h.MaxBodySize, func(body []byte) (err error) {
+		info, err = h.pay.ParseNotifyInfo(body)
+		return err
+	}, func() error {
+		return h.handle(info)
+	})
+}
+
+// 退款结果通知的 http.Handler，行为与 NotifyHandler 相同
+type RefundNotifyHandler struct {
+	MaxBodySize int64 // 通知报文最大长度，为 0 时使用 DEFAULT_NOTIFY_MAX_BODY_SIZE
+
+	pay    WechatPay
+	handle func(info *RefundNotifyInfo) error
+}
+
+func NewRefundNotifyHandler(pay WechatPay, handle func(info *RefundNotifyInfo) error) *RefundNotifyHandler {
+	return &RefundNotifyHandler{
+		pay:    pay,
+		handle: handle,
+	}
+}
+
+func (h *RefundNotifyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var info *RefundNotifyInfo
+	serveNotify(w, r, h.pay, h.MaxBodySize, func(body []byte) (err error) {
+		info, err = h.pay.ParseRefundNotify(body)
+		return err
+	}, func() error {
+		return h.handle(info)
+	})
+}
+
+// 读取并解析通知，解析成功后调用 handle
+// 回复中只使用固定的 return_msg，不回显错误信息，避免泄露签名等内部信息
+func serveNotify(w http.ResponseWriter, r *http.Request, pay WechatPay, maxBodySize int64, parse func(body []byte) error, handle func() error) {
+	if maxBodySize <= 0 {
+		maxBodySize = DEFAULT_NOTIFY_MAX_BODY_SIZE
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		writeNotifyReply(w, http.StatusBadRequest, "FAIL", NOTIFY_INVALID_REQUEST_MSG)
+		return
+	}
+
+	// 仿真测试时进程重启后首个通知需要先获取仿真测试密钥，获取失败时由微信稍后重新通知
+	if err := pay.LoadSandboxSignKey(r.Context()); err != nil {
+		writeNotifyReply(w, http.StatusOK, "FAIL", NOTIFY_HANDLE_FAIL_MSG)
+		return
+	}
+
+	if err := parse(body); err != nil {
+		// 通知本身合法但微信通知的是失败结果的，无需微信重复通知
+		if _, ok := err.(*ResultError); ok {
+			writeNotifyReply(w, http.StatusOK, "SUCCESS", "OK")
+			return
+		}
+
+		writeNotifyReply(w, http.StatusOK, "FAIL", NOTIFY_INVALID_REQUEST_MSG)
+		return
+	}
+
+	// handle 返回任何错误都回复 FAIL，由微信稍后重新通知
+	if err := handle(); err != nil {
+		writeNotifyReply(w, http.StatusOK, "FAIL", NOTIFY_HANDLE_FAIL_MSG)
+		return
+	}
+
+	writeNotifyReply(w, http.StatusOK, "SUCCESS", "OK")
+}
+
+func writeNotifyReply(w http.ResponseWriter, status int, returnCode, returnMsg string) {
+	reply, err := xml.Marshal(&NotifyReply{
+		ReturnCode: returnCode,
+		ReturnMsg:  returnMsg,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(reply)
+}
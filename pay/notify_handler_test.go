@@ -0,0 +1,78 @@
+package pay
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serveTestNotify(t *testing.T, handler http.Handler, body []byte) *NotifyReply {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/notify", bytes.NewReader(body)))
+
+	reply := &NotifyReply{}
+	if err := xml.Unmarshal(recorder.Body.Bytes(), reply); err != nil {
+		t.Fatalf("unmarshal reply return err: %v", err)
+	}
+	return reply
+}
+
+func Test_NotifyHandler(t *testing.T) {
+	body := signedNotifyBody(map[string]string{
+		"return_code":  "SUCCESS",
+		"result_code":  "SUCCESS",
+		"out_trade_no": "o0",
+	})
+
+	var got *NotifyInfo
+	handler := NewNotifyHandler(pay, func(info *NotifyInfo) error {
+		got = info
+		return nil
+	})
+
+	if reply := serveTestNotify(t, handler, body); reply.ReturnCode != "SUCCESS" {
+		t.Errorf("NotifyHandler should reply SUCCESS. get: %+v", reply)
+	}
+	if got == nil || got.OutTradeNo != "o0" {
+		t.Errorf("NotifyHandler should call handle with info. get: %+v", got)
+	}
+
+	forged := bytes.Replace(body, []byte("o0"), []byte("o1"), 1)
+	if reply := serveTestNotify(t, handler, forged); reply.ReturnCode != "FAIL" || reply.ReturnMsg != NOTIFY_INVALID_REQUEST_MSG {
+		t.Errorf("NotifyHandler should reply FAIL for forged body. get: %+v", reply)
+	}
+
+	failing := NewNotifyHandler(pay, func(info *NotifyInfo) error {
+		return errors.New("db down")
+	})
+	if reply := serveTestNotify(t, failing, body); reply.ReturnCode != "FAIL" || reply.ReturnMsg != NOTIFY_HANDLE_FAIL_MSG {
+		t.Errorf("NotifyHandler should reply FAIL when handle fail. get: %+v", reply)
+	}
+
+	resultFailing := NewNotifyHandler(pay, func(info *NotifyInfo) error {
+		return &ResultError{ReturnCode: "SUCCESS", ResultCode: "FAIL", ErrCode: ERR_CODE_SYSTEMERROR}
+	})
+	if reply := serveTestNotify(t, resultFailing, body); reply.ReturnCode != "FAIL" {
+		t.Errorf("NotifyHandler should reply FAIL when handle return *ResultError. get: %+v", reply)
+	}
+
+	called := false
+	unused := NewNotifyHandler(pay, func(info *NotifyInfo) error {
+		called = true
+		return nil
+	})
+	failBody := []byte("<xml><return_code><![CDATA[FAIL]]></return_code><return_msg><![CDATA[签名失败]]></return_msg></xml>")
+	if reply := serveTestNotify(t, unused, failBody); reply.ReturnCode != "SUCCESS" || called {
+		t.Errorf("NotifyHandler should ack return_code FAIL without calling handle. get: %+v, called: %v", reply, called)
+	}
+
+	limited := NewNotifyHandler(pay, func(info *NotifyInfo) error { return nil })
+	limited.MaxBodySize = 16
+	if reply := serveTestNotify(t, limited, []byte(strings.Repeat("x", 32))); reply.ReturnCode != "FAIL" {
+		t.Errorf("NotifyHandler should reply FAIL for large body. get: %+v", reply)
+	}
+}
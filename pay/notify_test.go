@@ -0,0 +1,63 @@
+package pay
+
+import (
+	"strings"
+	"testing"
+)
+
+func signedNotifyBody(values map[string]string) []byte {
+	values["sign"] = strings.ToUpper(md5Str(pay.genMapContentStr(values)))
+
+	body := "<xml>"
+	for name, value := range values {
+		body += "<" + name + "><![CDATA[" + value + "]]></" + name + ">"
+	}
+	return []byte(body + "</xml>")
+}
+
+func Test_wechatPay_ParseNotifyInfo(t *testing.T) {
+	values := map[string]string{
+		"return_code":    "SUCCESS",
+		"result_code":    "SUCCESS",
+		"appid":          "wx0",
+		"out_trade_no":   "o0",
+		"total_fee":      "100",
+		"coupon_count":   "1",
+		"coupon_id_0":    "c0",
+		"coupon_type_0":  "CASH",
+		"coupon_fee_0":   "10",
+		"transaction_id": "t0",
+	}
+	body := signedNotifyBody(values)
+
+	info, err := pay.ParseNotifyInfo(body)
+	if err != nil {
+		t.Fatalf("ParseNotifyInfo return err: %v", err)
+	}
+
+	if info.AppId != "wx0" || info.OutTradeNo != "o0" || info.TotalFee != 100 || len(info.Coupons) != 1 || info.Coupons[0].Fee != 10 {
+		t.Errorf("ParseNotifyInfo fail. get: %+v", info)
+	}
+
+	// 篡改结构体中未声明的动态字段
+	forged := strings.Replace(string(body), "<coupon_fee_0><![CDATA[10]]>", "<coupon_fee_0><![CDATA[1]]>", 1)
+	if _, err := pay.ParseNotifyInfo([]byte(forged)); err == nil {
+		t.Errorf("ParseNotifyInfo should fail for forged body")
+	} else if _, ok := err.(*SignError); !ok {
+		t.Errorf("ParseNotifyInfo should return *SignError. get: %T", err)
+	}
+
+	if _, err := pay.ParseNotifyInfo([]byte("<xml><return_code>")); err == nil {
+		t.Errorf("ParseNotifyInfo should fail for malformed body")
+	} else if _, ok := err.(*FormatError); !ok {
+		t.Errorf("ParseNotifyInfo should return *FormatError. get: %T", err)
+	}
+
+	values["result_code"] = "FAIL"
+	values["err_code"] = "SYSTEMERROR"
+	if _, err := pay.ParseNotifyInfo(signedNotifyBody(values)); err == nil {
+		t.Errorf("ParseNotifyInfo should fail for result FAIL")
+	} else if e, ok := err.(*ResultError); !ok || e.ErrCode != "SYSTEMERROR" {
+		t.Errorf("ParseNotifyInfo should return *ResultError. get: %v", err)
+	}
+}
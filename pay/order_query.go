@@ -0,0 +1,141 @@
+package pay
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"strconv"
+)
+
+// 微信支付查询订单接口
+// https://pay.weixin.qq.com/wiki/doc/api/jsapi.php?chapter=9_2
+
+const (
+	ORDER_QUERY_URL = "https://api.mch.weixin.qq.com/pay/orderquery"
+)
+
+type TradeState string
+
+const (
+	TRADE_STATE_SUCCESS    TradeState = "SUCCESS"    // 支付成功
+	TRADE_STATE_REFUND     TradeState = "REFUND"     // 转入退款
+	TRADE_STATE_NOTPAY     TradeState = "NOTPAY"     // 未支付
+	TRADE_STATE_CLOSED     TradeState = "CLOSED"     // 已关闭
+	TRADE_STATE_REVOKED    TradeState = "REVOKED"    // 已撤销（付款码支付）
+	TRADE_STATE_USERPAYING TradeState = "USERPAYING" // 用户支付中（付款码支付）
+	TRADE_STATE_PAYERROR   TradeState = "PAYERROR"   // 支付失败(其他原因，如银行返回失败)
+)
+
+type OrderQueryParam struct {
+	AppId         string `xml:"appid"`
+	Mchid         string `xml:"mch_id"`
+	TransactionId string `xml:"transaction_id"` // 微信订单号，与商户订单号需要二选一填写
+	OutTradeNo    string `xml:"out_trade_no"`   // 商户订单号，与微信订单号需要二选一填写
+	NonceStr      string `xml:"nonce_str"`
+	Sign          string `xml:"sign"`
+	SignType      string `xml:"sign_type"`
+}
+
+type OrderQueryResponse struct {
+	ReturnCode string `xml:"return_code"`
+	ReturnMsg  string `xml:"return_msg"`
+	AppId      string `xml:"appid"`
+	MchId      string `xml:"mch_id"`
+	NonceStr   string `xml:"nonce_str"`
+	Sign       string `xml:"sign"`
+	ResultCode string `xml:"result_code"`
+	ErrCode    string `xml:"err_code"`
+	ErrCodeDes string `xml:"err_code_des"`
+
+	DeviceInfo         string     `xml:"device_info"`
+	Openid             string     `xml:"openid"`
+	IsSubscribe        string     `xml:"is_subscribe"` // Y-> yes, N-> no
+	TradeType          string     `xml:"trade_type"`   // JSAPI、NATIVE、APP、MICROPAY
+	TradeState         TradeState `xml:"trade_state"`
+	BankType           string     `xml:"bank_type"`
+	TotalFee           int64      `xml:"total_fee"`            // 订单总金额，单位为分
+	SettlementTotalFee int64      `xml:"settlement_total_fee"` // 应结订单金额=订单金额-非充值代金券金额
+	FeeType            string     `xml:"fee_type"`
+	CashFee            int64      `xml:"cash_fee"` // 现金支付金额
+	CashFeeType        string     `xml:"cash_fee_type"`
+	CouponFee          int64      `xml:"coupon_fee"`   // 代金券金额
+	CouponCount        int        `xml:"coupon_count"` // 代金券使用数量
+	TransactionId      string     `xml:"transaction_id"`
+	OutTradeNo         string     `xml:"out_trade_no"`
+	Attach             string     `xml:"attach"`
+	TimeEnd            string     `xml:"time_end"` // 支付完成时间，格式为yyyyMMddHHmmss
+	TradeStateDesc     string     `xml:"trade_state_desc"`
+
+	Coupons []Coupon `xml:"-"` // 由 coupon_type_$n、coupon_id_$n、coupon_fee_$n 解析得到
+}
+
+type Coupon struct {
+	Id   string // 代金券ID
+	Type string // CASH--充值代金券，NO_CASH---非充值优惠券
+	Fee  int64  // 单个代金券支付金额
+}
+
+// 查询订单，transactionId 与 outTradeNo 二选一，同时存在时微信优先使用 transactionId
+func (self *wechatPay) OrderQuery(transactionId, outTradeNo string, options ...RequestOption) (*OrderQueryResponse, error) {
+	return self.OrderQueryWithContext(context.Background(), transactionId, outTradeNo, options...)
+}
+
+func (self *wechatPay) OrderQueryWithContext(ctx context.Context, transactionId, outTradeNo string, options ...RequestOption) (*OrderQueryResponse, error) {
+	if transactionId == "" && outTradeNo == "" {
+		return nil, errors.New("transaction_id or out_trade_no is required")
+	}
+
+	param := &OrderQueryParam{
+		AppId:         self.AppId,
+		Mchid:         self.mchId,
+		TransactionId: transactionId,
+		OutTradeNo:    outTradeNo,
+		NonceStr:      randString(self.NonceLen),
+		SignType:      string(self.requestOption(options).signType),
+	}
+
+	sign, err := self.signRequest(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	param.Sign = sign
+
+	data, values, err := self.post(ctx, self.nonSecureClient, ORDER_QUERY_URL, param)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &OrderQueryResponse{}
+	if err = xml.Unmarshal(data, resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Coupons, err = parseCoupons(values, resp.CouponCount); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// 解析 coupon_type_$n、coupon_id_$n、coupon_fee_$n 字段，$n 从 0 开始
+func parseCoupons(values map[string]string, count int) ([]Coupon, error) {
+	coupons := make([]Coupon, 0, count)
+
+	for i := 0; i < count; i++ {
+		n := strconv.Itoa(i)
+
+		fee, err := int64Value(values, "coupon_fee_"+n)
+		if err != nil {
+			return nil, err
+		}
+
+		coupons = append(coupons, Coupon{
+			Id:   values["coupon_id_"+n],
+			Type: values["coupon_type_"+n],
+			Fee:  fee,
+		})
+	}
+
+	return coupons, nil
+}
@@ -0,0 +1,41 @@
+package pay
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseCoupons(t *testing.T) {
+	data := []byte(`<xml>
+<return_code><![CDATA[SUCCESS]]></return_code>
+<coupon_count>2</coupon_count>
+<coupon_id_0><![CDATA[10000]]></coupon_id_0>
+<coupon_type_0><![CDATA[CASH]]></coupon_type_0>
+<coupon_fee_0>100</coupon_fee_0>
+<coupon_id_1><![CDATA[10001]]></coupon_id_1>
+<coupon_type_1><![CDATA[NO_CASH]]></coupon_type_1>
+<coupon_fee_1>5</coupon_fee_1>
+</xml>`)
+
+	values, err := parseXMLMap(data)
+	if err != nil {
+		t.Fatalf("parseXMLMap return err: %v", err)
+	}
+
+	if values["return_code"] != "SUCCESS" {
+		t.Errorf("parseXMLMap fail for cdata. want: SUCCESS. get: %v", values["return_code"])
+	}
+
+	coupons, err := parseCoupons(values, 2)
+	if err != nil {
+		t.Fatalf("parseCoupons return err: %v", err)
+	}
+
+	want := []Coupon{
+		{Id: "10000", Type: "CASH", Fee: 100},
+		{Id: "10001", Type: "NO_CASH", Fee: 5},
+	}
+	if !reflect.DeepEqual(coupons, want) {
+		t.Errorf("parseCoupons fail. want: %v. get: %v", want, coupons)
+	}
+}
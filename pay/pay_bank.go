@@ -0,0 +1,293 @@
+package pay
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+)
+
+/*
+企业付款到银行卡，收款方银行卡号与姓名需要使用微信提供的 RSA 公钥加密
+https://pay.weixin.qq.com/wiki/doc/api/tools/mch_pay.php?chapter=24_2
+*/
+
+const (
+	GET_PUBLIC_KEY_URL = "https://fraud.mch.weixin.qq.com/risk/getpublickey"
+	PAY_BANK_URL       = "https://api.mch.weixin.qq.com/mmpaysptrans/pay_bank"
+	QUERY_BANK_URL     = "https://api.mch.weixin.qq.com/mmpaysptrans/query_bank"
+)
+
+// 收款方开户行
+type BankCode string
+
+const (
+	BANK_CODE_ICBC   BankCode = "1002" // 工商银行
+	BANK_CODE_ABC    BankCode = "1005" // 农业银行
+	BANK_CODE_BOC    BankCode = "1026" // 中国银行
+	BANK_CODE_CCB    BankCode = "1003" // 建设银行
+	BANK_CODE_CMB    BankCode = "1001" // 招商银行
+	BANK_CODE_PSBC   BankCode = "1066" // 邮储银行
+	BANK_CODE_BCOM   BankCode = "1020" // 交通银行
+	BANK_CODE_SPDB   BankCode = "1004" // 浦发银行
+	BANK_CODE_CMBC   BankCode = "1006" // 民生银行
+	BANK_CODE_CIB    BankCode = "1009" // 兴业银行
+	BANK_CODE_PAB    BankCode = "1010" // 平安银行
+	BANK_CODE_CITIC  BankCode = "1021" // 中信银行
+	BANK_CODE_HXB    BankCode = "1025" // 华夏银行
+	BANK_CODE_CGB    BankCode = "1027" // 广发银行
+	BANK_CODE_CEB    BankCode = "1022" // 光大银行
+	BANK_CODE_BOB    BankCode = "1032" // 北京银行
+	BANK_CODE_NBBANK BankCode = "1056" // 宁波银行
+)
+
+// 银行编号对应的银行名称
+var BankNames = map[BankCode]string{
+	BANK_CODE_ICBC:   "工商银行",
+	BANK_CODE_ABC:    "农业银行",
+	BANK_CODE_BOC:    "中国银行",
+	BANK_CODE_CCB:    "建设银行",
+	BANK_CODE_CMB:    "招商银行",
+	BANK_CODE_PSBC:   "邮储银行",
+	BANK_CODE_BCOM:   "交通银行",
+	BANK_CODE_SPDB:   "浦发银行",
+	BANK_CODE_CMBC:   "民生银行",
+	BANK_CODE_CIB:    "兴业银行",
+	BANK_CODE_PAB:    "平安银行",
+	BANK_CODE_CITIC:  "中信银行",
+	BANK_CODE_HXB:    "华夏银行",
+	BANK_CODE_CGB:    "广发银行",
+	BANK_CODE_CEB:    "光大银行",
+	BANK_CODE_BOB:    "北京银行",
+	BANK_CODE_NBBANK: "宁波银行",
+}
+
+type BankPayStatus string
+
+const (
+	BANK_PAY_STATUS_PROCESSING BankPayStatus = "PROCESSING" // 处理中
+	BANK_PAY_STATUS_SUCCESS    BankPayStatus = "SUCCESS"    // 付款成功
+	BANK_PAY_STATUS_FAILED     BankPayStatus = "FAILED"     // 付款失败，资金会退回商户
+	BANK_PAY_STATUS_BANK_FAIL  BankPayStatus = "BANK_FAIL"  // 银行退票，资金会退回商户
+)
+
+type getPublicKeyParam struct {
+	Mchid    string `xml:"mch_id"`
+	NonceStr string `xml:"nonce_str"`
+	Sign     string `xml:"sign"`
+	SignType string `xml:"sign_type"`
+}
+
+type payBankParam struct {
+	Mchid          string `xml:"mch_id"`
+	PartnerTradeNo string `xml:"partner_trade_no"`
+	NonceStr       string `xml:"nonce_str"`
+	Sign           string `xml:"sign"`
+	EncBankNo      string `xml:"enc_bank_no"`   // 加密后的收款方银行卡号
+	EncTrueName    string `xml:"enc_true_name"` // 加密后的收款方用户名
+	BankCode       string `xml:"bank_code"`
+	Amount         int64  `xml:"amount"`
+	Desc           string `xml:"desc"`
+}
+
+type PayBankResponse struct {
+	ReturnCode string `xml:"return_code"`
+	ReturnMsg  string `xml:"return_msg"`
+	ResultCode string `xml:"result_code"`
+	ErrCode    string `xml:"err_code"`
+	ErrCodeDes string `xml:"err_code_des"`
+
+	MchId          string `xml:"mch_id"`
+	PartnerTradeNo string `xml:"partner_trade_no"` // 商户企业付款单号
+	Amount         int64  `xml:"amount"`           // 代付金额，单位为分
+	NonceStr       string `xml:"nonce_str"`
+	Sign           string `xml:"sign"`
+	PaymentNo      string `xml:"payment_no"` // 微信企业付款单号
+	CmmsAmt        int64  `xml:"cmms_amt"`   // 手续费金额，单位为分
+}
+
+type queryBankParam struct {
+	Mchid          string `xml:"mch_id"`
+	PartnerTradeNo string `xml:"partner_trade_no"`
+	NonceStr       string `xml:"nonce_str"`
+	Sign           string `xml:"sign"`
+}
+
+type QueryBankResponse struct {
+	ReturnCode string `xml:"return_code"`
+	ReturnMsg  string `xml:"return_msg"`
+	ResultCode string `xml:"result_code"`
+	ErrCode    string `xml:"err_code"`
+	ErrCodeDes string `xml:"err_code_des"`
+
+	MchId          string        `xml:"mch_id"`
+	PartnerTradeNo string        `xml:"partner_trade_no"`
+	PaymentNo      string        `xml:"payment_no"`
+	BankNoMd5      string        `xml:"bank_no_md5"`   // 收款用户银行卡号的 md5 值
+	TrueNameMd5    string        `xml:"true_name_md5"` // 收款人真实姓名的 md5 值
+	Amount         int64         `xml:"amount"`
+	Status         BankPayStatus `xml:"status"`
+	CmmsAmt        int64         `xml:"cmms_amt"`
+	CreateTime     string        `xml:"create_time"`   // 商户下单时间
+	PaySuccTime    string        `xml:"pay_succ_time"` // 微信侧付款成功时间，不代表银行入账时间
+	Reason         string        `xml:"reason"`        // 付款失败原因
+}
+
+// 企业付款到银行卡，bankNo 与 trueName 使用 RSA 公钥加密后发送，公钥在首次调用时获取并缓存
+func (self *wechatPay) PayBank(partnerTradeNo, bankNo, trueName string, bankCode BankCode, amount int64, desc string) (*PayBankResponse, error) {
+	return self.PayBankWithContext(context.Background(), partnerTradeNo, bankNo, trueName, bankCode, amount, desc)
+}
+
+func (self *wechatPay) PayBankWithContext(ctx context.Context, partnerTradeNo, bankNo, trueName string, bankCode BankCode, amount int64, desc string) (*PayBankResponse, error) {
+	publicKey, err := self.getPublicKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	encBankNo, err := rsaEncrypt(publicKey, bankNo)
+	if err != nil {
+		return nil, err
+	}
+
+	encTrueName, err := rsaEncrypt(publicKey, trueName)
+	if err != nil {
+		return nil, err
+	}
+
+	param := &payBankParam{
+		Mchid:          self.mchId,
+		PartnerTradeNo: partnerTradeNo,
+		NonceStr:       randString(self.NonceLen),
+		EncBankNo:      encBankNo,
+		EncTrueName:    encTrueName,
+		BankCode:       string(bankCode),
+		Amount:         amount,
+		Desc:           desc,
+	}
+
+	sign, err := self.signRequest(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	param.Sign = sign
+
+	data, _, err := self.post(ctx, self.secureClient, PAY_BANK_URL, param)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &PayBankResponse{}
+	if err = xml.Unmarshal(data, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// 查询企业付款到银行卡的结果
+func (self *wechatPay) QueryBank(partnerTradeNo string) (*QueryBankResponse, error) {
+	return self.QueryBankWithContext(context.Background(), partnerTradeNo)
+}
+
+func (self *wechatPay) QueryBankWithContext(ctx context.Context, partnerTradeNo string) (*QueryBankResponse, error) {
+	param := &queryBankParam{
+		Mchid:          self.mchId,
+		PartnerTradeNo: partnerTradeNo,
+		NonceStr:       randString(self.NonceLen),
+	}
+
+	sign, err := self.signRequest(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	param.Sign = sign
+
+	data, _, err := self.post(ctx, self.secureClient, QUERY_BANK_URL, param)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &QueryBankResponse{}
+	if err = xml.Unmarshal(data, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// 获取并缓存企业付款到银行卡使用的 RSA 公钥
+func (self *wechatPay) getPublicKey(ctx context.Context) (*rsa.PublicKey, error) {
+	self.publicKeyLock.Lock()
+	defer self.publicKeyLock.Unlock()
+
+	if self.publicKey != nil {
+		return self.publicKey, nil
+	}
+
+	param := &getPublicKeyParam{
+		Mchid:    self.mchId,
+		NonceStr: randString(self.NonceLen),
+		SignType: string(SIGN_TYPE_MD5),
+	}
+
+	sign, err := self.signRequest(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	param.Sign = sign
+
+	_, values, err := self.post(ctx, self.secureClient, GET_PUBLIC_KEY_URL, param)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, err := parseRSAPublicKey(values["pub_key"])
+	if err != nil {
+		return nil, err
+	}
+
+	self.publicKey = publicKey
+	return publicKey, nil
+}
+
+// 微信返回 PKCS#1 格式的 PEM 公钥
+func parseRSAPublicKey(pemKey string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, errors.New("invalid pem public key")
+	}
+
+	if publicKey, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return publicKey, nil
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("public key is not rsa")
+	}
+
+	return publicKey, nil
+}
+
+// RSA-OAEP 加密，填充方式为 RSA_PKCS1_OAEP_PADDING，结果以 base64 编码
+func rsaEncrypt(publicKey *rsa.PublicKey, plain string) (string, error) {
+	encrypted, err := rsa.EncryptOAEP(sha1.New(), rand.Reader, publicKey, []byte(plain), nil)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(encrypted), nil
+}
@@ -0,0 +1,51 @@
+package pay
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+)
+
+func Test_rsaEncrypt(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey return err: %v", err)
+	}
+
+	pemKey := string(pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PUBLIC KEY",
+		Bytes: x509.MarshalPKCS1PublicKey(&privateKey.PublicKey),
+	}))
+
+	publicKey, err := parseRSAPublicKey(pemKey)
+	if err != nil {
+		t.Fatalf("parseRSAPublicKey return err: %v", err)
+	}
+
+	encrypted, err := rsaEncrypt(publicKey, "6225888888888888")
+	if err != nil {
+		t.Fatalf("rsaEncrypt return err: %v", err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		t.Fatalf("decode base64 return err: %v", err)
+	}
+
+	plain, err := rsa.DecryptOAEP(sha1.New(), rand.Reader, privateKey, data, nil)
+	if err != nil {
+		t.Fatalf("DecryptOAEP return err: %v", err)
+	}
+
+	if string(plain) != "6225888888888888" {
+		t.Errorf("rsaEncrypt fail. want: 6225888888888888. get: %v", string(plain))
+	}
+
+	if _, err := parseRSAPublicKey("not a key"); err == nil {
+		t.Errorf("parseRSAPublicKey should fail for invalid key")
+	}
+}
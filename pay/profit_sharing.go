@@ -0,0 +1,504 @@
+package pay
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/http"
+)
+
+/*
+分账，所有接口只支持 HMAC-SHA256 签名，分账接收方以 JSON 格式放在 xml 字段中
+https://pay.weixin.qq.com/wiki/doc/api/allocation.php?chapter=27_1&index=1
+*/
+
+const (
+	PROFIT_SHARING_ADD_RECEIVER_URL    = "https://api.mch.weixin.qq.com/pay/profitsharingaddreceiver"
+	PROFIT_SHARING_REMOVE_RECEIVER_URL = "https://api.mch.weixin.qq.com/pay/profitsharingremovereceiver"
+	PROFIT_SHARING_URL                 = "https://api.mch.weixin.qq.com/secapi/pay/profitsharing"
+	MULTI_PROFIT_SHARING_URL           = "https://api.mch.weixin.qq.com/secapi/pay/multiprofitsharing"
+	PROFIT_SHARING_QUERY_URL           = "https://api.mch.weixin.qq.com/pay/profitsharingquery"
+	PROFIT_SHARING_FINISH_URL          = "https://api.mch.weixin.qq.com/secapi/pay/profitsharingfinish"
+	PROFIT_SHARING_RETURN_URL          = "https://api.mch.weixin.qq.com/secapi/pay/profitsharingreturn"
+	PROFIT_SHARING_RETURN_QUERY_URL    = "https://api.mch.weixin.qq.com/pay/profitsharingreturnquery"
+)
+
+// 分账接收方类型
+type ReceiverType string
+
+const (
+	RECEIVER_TYPE_MERCHANT_ID       ReceiverType = "MERCHANT_ID"       // 商户号
+	RECEIVER_TYPE_PERSONAL_WECHATID ReceiverType = "PERSONAL_WECHATID" // 个人微信号
+	RECEIVER_TYPE_PERSONAL_OPENID   ReceiverType = "PERSONAL_OPENID"   // 个人openid
+)
+
+// 与分账方的关系类型
+type RelationType string
+
+const (
+	RELATION_TYPE_SERVICE_PROVIDER RelationType = "SERVICE_PROVIDER" // 服务商
+	RELATION_TYPE_STORE            RelationType = "STORE"            // 门店
+	RELATION_TYPE_STAFF            RelationType = "STAFF"            // 员工
+	RELATION_TYPE_STORE_OWNER      RelationType = "STORE_OWNER"      // 店主
+	RELATION_TYPE_PARTNER          RelationType = "PARTNER"          // 合作伙伴
+	RELATION_TYPE_HEADQUARTER      RelationType = "HEADQUARTER"      // 总部
+	RELATION_TYPE_BRAND            RelationType = "BRAND"            // 品牌方
+	RELATION_TYPE_DISTRIBUTOR      RelationType = "DISTRIBUTOR"      // 分销商
+	RELATION_TYPE_USER             RelationType = "USER"             // 用户
+	RELATION_TYPE_SUPPLIER         RelationType = "SUPPLIER"         // 供应商
+	RELATION_TYPE_CUSTOM           RelationType = "CUSTOM"           // 自定义
+)
+
+// 分账单状态
+type ProfitSharingStatus string
+
+const (
+	PROFIT_SHARING_STATUS_ACCEPTED   ProfitSharingStatus = "ACCEPTED"   // 受理成功
+	PROFIT_SHARING_STATUS_PROCESSING ProfitSharingStatus = "PROCESSING" // 处理中
+	PROFIT_SHARING_STATUS_FINISHED   ProfitSharingStatus = "FINISHED"   // 处理完成
+	PROFIT_SHARING_STATUS_CLOSED     ProfitSharingStatus = "CLOSED"     // 处理失败，已关单
+)
+
+// 分账、分账回退的处理结果
+type ProfitSharingResult string
+
+const (
+	PROFIT_SHARING_RESULT_PENDING    ProfitSharingResult = "PENDING"    // 待分账
+	PROFIT_SHARING_RESULT_PROCESSING ProfitSharingResult = "PROCESSING" // 处理中
+	PROFIT_SHARING_RESULT_SUCCESS    ProfitSharingResult = "SUCCESS"    // 成功
+	PROFIT_SHARING_RESULT_FAILED     ProfitSharingResult = "FAILED"     // 失败
+	PROFIT_SHARING_RESULT_CLOSED     ProfitSharingResult = "CLOSED"     // 已关闭
+)
+
+// 分账接收方，用于添加、删除分账接收方
+type ProfitSharingReceiver struct {
+	Type           ReceiverType `json:"type"`
+	Account        string       `json:"account"`                   // 类型为 MERCHANT_ID 时为商户号，PERSONAL_WECHATID 时为微信号，PERSONAL_OPENID 时为 openid
+	Name           string       `json:"name,omitempty"`            // 类型为 MERCHANT_ID 时必填，为商户全称
+	RelationType   RelationType `json:"relation_type,omitempty"`   // 删除接收方时不需要
+	CustomRelation string       `json:"custom_relation,omitempty"` // relation_type 为 CUSTOM 时必填
+}
+
+// 分账时的接收方与分账金额
+type ProfitSharingAmount struct {
+	Type        ReceiverType `json:"type"`
+	Account     string       `json:"account"`
+	Amount      int64        `json:"amount"` // 分账金额，单位为分
+	Description string       `json:"description"`
+	Name        string       `json:"name,omitempty"` // 类型为 PERSONAL_OPENID 时可选，传入时会校验姓名
+}
+
+// 分账查询返回的接收方分账结果
+type ProfitSharingReceiverResult struct {
+	Type        ReceiverType        `json:"type"`
+	Account     string              `json:"account"`
+	Amount      int64               `json:"amount"`
+	Description string              `json:"description"`
+	Result      ProfitSharingResult `json:"result"`
+	FinishTime  string              `json:"finish_time"`
+	FailReason  string              `json:"fail_reason"`
+}
+
+type profitSharingReceiverParam struct {
+	Mchid    string `xml:"mch_id"`
+	AppId    string `xml:"appid"`
+	NonceStr string `xml:"nonce_str"`
+	Sign     string `xml:"sign"`
+	SignType string `xml:"sign_type"`
+	Receiver string `xml:"receiver"` // ProfitSharingReceiver 的 JSON
+}
+
+type ProfitSharingReceiverResponse struct {
+	ReturnCode string `xml:"return_code"`
+	ReturnMsg  string `xml:"return_msg"`
+	ResultCode string `xml:"result_code"`
+	ErrCode    string `xml:"err_code"`
+	ErrCodeDes string `xml:"err_code_des"`
+	MchId      string `xml:"mch_id"`
+	AppId      string `xml:"appid"`
+	NonceStr   string `xml:"nonce_str"`
+	Sign       string `xml:"sign"`
+
+	Receiver string `xml:"receiver"` // 分账接收方的 JSON
+}
+
+type profitSharingParam struct {
+	Mchid         string `xml:"mch_id"`
+	AppId         string `xml:"appid"`
+	NonceStr      string `xml:"nonce_str"`
+	Sign          string `xml:"sign"`
+	SignType      string `xml:"sign_type"`
+	TransactionId string `xml:"transaction_id"`
+	OutOrderNo    string `xml:"out_order_no"` // 商户分账单号
+	Receivers     string `xml:"receivers"`    // []ProfitSharingAmount 的 JSON
+}
+
+type ProfitSharingResponse struct {
+	ReturnCode string `xml:"return_code"`
+	ReturnMsg  string `xml:"return_msg"`
+	ResultCode string `xml:"result_code"`
+	ErrCode    string `xml:"err_code"`
+	ErrCodeDes string `xml:"err_code_des"`
+	MchId      string `xml:"mch_id"`
+	AppId      string `xml:"appid"`
+	NonceStr   string `xml:"nonce_str"`
+	Sign       string `xml:"sign"`
+
+	TransactionId string `xml:"transaction_id"`
+	OutOrderNo    string `xml:"out_order_no"`
+	OrderId       string `xml:"order_id"` // 微信分账单号
+}
+
+type profitSharingQueryParam struct {
+	Mchid         string `xml:"mch_id"`
+	TransactionId string `xml:"transaction_id"`
+	OutOrderNo    string `xml:"out_order_no"`
+	NonceStr      string `xml:"nonce_str"`
+	Sign          string `xml:"sign"`
+	SignType      string `xml:"sign_type"`
+}
+
+type ProfitSharingQueryResponse struct {
+	ReturnCode string `xml:"return_code"`
+	ReturnMsg  string `xml:"return_msg"`
+	ResultCode string `xml:"result_code"`
+	ErrCode    string `xml:"err_code"`
+	ErrCodeDes string `xml:"err_code_des"`
+	MchId      string `xml:"mch_id"`
+	NonceStr   string `xml:"nonce_str"`
+	Sign       string `xml:"sign"`
+
+	TransactionId string              `xml:"transaction_id"`
+	OutOrderNo    string              `xml:"out_order_no"`
+	OrderId       string              `xml:"order_id"`
+	Status        ProfitSharingStatus `xml:"status"`
+	CloseReason   string              `xml:"close_reason"`
+	Amount        int64               `xml:"amount"`      // 完结分账时的分账金额
+	Description   string              `xml:"description"` // 完结分账时的分账描述
+
+	Receivers []ProfitSharingReceiverResult `xml:"-"` // 由 receivers 字段的 JSON 解析得到
+}
+
+type profitSharingFinishParam struct {
+	Mchid         string `xml:"mch_id"`
+	AppId         string `xml:"appid"`
+	NonceStr      string `xml:"nonce_str"`
+	Sign          string `xml:"sign"`
+	SignType      string `xml:"sign_type"`
+	TransactionId string `xml:"transaction_id"`
+	OutOrderNo    string `xml:"out_order_no"`
+	Amount        int64  `xml:"amount"` // 固定为 0
+	Description   string `xml:"description"`
+}
+
+type profitSharingReturnParam struct {
+	Mchid             string `xml:"mch_id"`
+	AppId             string `xml:"appid"`
+	NonceStr          string `xml:"nonce_str"`
+	Sign              string `xml:"sign"`
+	SignType          string `xml:"sign_type"`
+	OrderId           string `xml:"order_id"`     // 微信分账单号，与商户分账单号二选一
+	OutOrderNo        string `xml:"out_order_no"` // 商户分账单号
+	OutReturnNo       string `xml:"out_return_no"`
+	ReturnAccountType string `xml:"return_account_type"` // 固定为 MERCHANT_ID
+	ReturnAccount     string `xml:"return_account"`
+	ReturnAmount      int64  `xml:"return_amount"`
+	Description       string `xml:"description"`
+}
+
+type profitSharingReturnQueryParam struct {
+	Mchid       string `xml:"mch_id"`
+	AppId       string `xml:"appid"`
+	NonceStr    string `xml:"nonce_str"`
+	Sign        string `xml:"sign"`
+	SignType    string `xml:"sign_type"`
+	OrderId     string `xml:"order_id"`
+	OutOrderNo  string `xml:"out_order_no"`
+	OutReturnNo string `xml:"out_return_no"`
+}
+
+// 分账回退与回退查询的返回
+type ProfitSharingReturnResponse struct {
+	ReturnCode string `xml:"return_code"`
+	ReturnMsg  string `xml:"return_msg"`
+	ResultCode string `xml:"result_code"`
+	ErrCode    string `xml:"err_code"`
+	ErrCodeDes string `xml:"err_code_des"`
+	MchId      string `xml:"mch_id"`
+	AppId      string `xml:"appid"`
+	NonceStr   string `xml:"nonce_str"`
+	Sign       string `xml:"sign"`
+
+	OrderId           string              `xml:"order_id"`
+	OutOrderNo        string              `xml:"out_order_no"`
+	OutReturnNo       string              `xml:"out_return_no"`
+	ReturnNo          string              `xml:"return_no"` // 微信回退单号
+	ReturnAccountType string              `xml:"return_account_type"`
+	ReturnAccount     string              `xml:"return_account"`
+	ReturnAmount      int64               `xml:"return_amount"`
+	Description       string              `xml:"description"`
+	Result            ProfitSharingResult `xml:"result"` // PROCESSING、SUCCESS、FAILED
+	FailReason        string              `xml:"fail_reason"`
+	FinishTime        string              `xml:"finish_time"`
+}
+
+// 添加分账接收方
+func (self *wechatPay) ProfitSharingAddReceiver(receiver *ProfitSharingReceiver) (*ProfitSharingReceiverResponse, error) {
+	return self.ProfitSharingAddReceiverWithContext(context.Background(), receiver)
+}
+
+func (self *wechatPay) ProfitSharingAddReceiverWithContext(ctx context.Context, receiver *ProfitSharingReceiver) (*ProfitSharingReceiverResponse, error) {
+	return self.profitSharingReceiver(ctx, PROFIT_SHARING_ADD_RECEIVER_URL, receiver)
+}
+
+// 删除分账接收方，只需要 Type 与 Account
+func (self *wechatPay) ProfitSharingRemoveReceiver(receiver *ProfitSharingReceiver) (*ProfitSharingReceiverResponse, error) {
+	return self.ProfitSharingRemoveReceiverWithContext(context.Background(), receiver)
+}
+
+func (self *wechatPay) ProfitSharingRemoveReceiverWithContext(ctx context.Context, receiver *ProfitSharingReceiver) (*ProfitSharingReceiverResponse, error) {
+	return self.profitSharingReceiver(ctx, PROFIT_SHARING_REMOVE_RECEIVER_URL, receiver)
+}
+
+// 单次分账，分账后剩余的资金自动解冻给本商户
+func (self *wechatPay) ProfitSharing(transactionId, outOrderNo string, receivers []ProfitSharingAmount) (*ProfitSharingResponse, error) {
+	return self.ProfitSharingWithContext(context.Background(), transactionId, outOrderNo, receivers)
+}
+
+func (self *wechatPay) ProfitSharingWithContext(ctx context.Context, transactionId, outOrderNo string, receivers []ProfitSharingAmount) (*ProfitSharingResponse, error) {
+	return self.profitSharing(ctx, PROFIT_SHARING_URL, transactionId, outOrderNo, receivers)
+}
+
+// 多次分账，分账后剩余资金仍冻结，需要调用 ProfitSharingFinish 完结分账
+func (self *wechatPay) MultiProfitSharing(transactionId, outOrderNo string, receivers []ProfitSharingAmount) (*ProfitSharingResponse, error) {
+	return self.MultiProfitSharingWithContext(context.Background(), transactionId, outOrderNo, receivers)
+}
+
+func (self *wechatPay) MultiProfitSharingWithContext(ctx context.Context, transactionId, outOrderNo string, receivers []ProfitSharingAmount) (*ProfitSharingResponse, error) {
+	return self.profitSharing(ctx, MULTI_PROFIT_SHARING_URL, transactionId, outOrderNo, receivers)
+}
+
+// 查询分账结果
+func (self *wechatPay) ProfitSharingQuery(transactionId, outOrderNo string) (*ProfitSharingQueryResponse, error) {
+	return self.ProfitSharingQueryWithContext(context.Background(), transactionId, outOrderNo)
+}
+
+func (self *wechatPay) ProfitSharingQueryWithContext(ctx context.Context, transactionId, outOrderNo string) (*ProfitSharingQueryResponse, error) {
+	param := &profitSharingQueryParam{
+		Mchid:         self.mchId,
+		TransactionId: transactionId,
+		OutOrderNo:    outOrderNo,
+		NonceStr:      randString(self.NonceLen),
+		SignType:      string(SIGN_TYPE_HMAC_SHA256),
+	}
+
+	sign, err := self.signRequest(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	param.Sign = sign
+
+	data, values, err := self.post(ctx, self.nonSecureClient, PROFIT_SHARING_QUERY_URL, param)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &ProfitSharingQueryResponse{}
+	if err = xml.Unmarshal(data, resp); err != nil {
+		return nil, err
+	}
+
+	if receivers := values["receivers"]; receivers != "" {
+		if err = json.Unmarshal([]byte(receivers), &resp.Receivers); err != nil {
+			return nil, &FormatError{Err: err}
+		}
+	}
+
+	return resp, nil
+}
+
+// 完结分账，将订单剩余的待分账金额全部解冻给本商户
+func (self *wechatPay) ProfitSharingFinish(transactionId, outOrderNo, description string) (*ProfitSharingResponse, error) {
+	return self.ProfitSharingFinishWithContext(context.Background(), transactionId, outOrderNo, description)
+}
+
+func (self *wechatPay) ProfitSharingFinishWithContext(ctx context.Context, transactionId, outOrderNo, description string) (*ProfitSharingResponse, error) {
+	param := &profitSharingFinishParam{
+		Mchid:         self.mchId,
+		AppId:         self.AppId,
+		NonceStr:      randString(self.NonceLen),
+		SignType:      string(SIGN_TYPE_HMAC_SHA256),
+		TransactionId: transactionId,
+		OutOrderNo:    outOrderNo,
+		Description:   description,
+	}
+
+	sign, err := self.signRequest(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	param.Sign = sign
+
+	data, _, err := self.post(ctx, self.secureClient, PROFIT_SHARING_FINISH_URL, param)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &ProfitSharingResponse{}
+	if err = xml.Unmarshal(data, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// 分账回退，将已分给商户类型接收方的资金退回本商户，orderId 与 outOrderNo 二选一
+func (self *wechatPay) ProfitSharingReturn(orderId, outOrderNo, outReturnNo, returnAccount string, returnAmount int64, description string) (*ProfitSharingReturnResponse, error) {
+	return self.ProfitSharingReturnWithContext(context.Background(), orderId, outOrderNo, outReturnNo, returnAccount, returnAmount, description)
+}
+
+func (self *wechatPay) ProfitSharingReturnWithContext(ctx context.Context, orderId, outOrderNo, outReturnNo, returnAccount string, returnAmount int64, description string) (*ProfitSharingReturnResponse, error) {
+	if orderId == "" && outOrderNo == "" {
+		return nil, errors.New("order_id or out_order_no is required")
+	}
+
+	param := &profitSharingReturnParam{
+		Mchid:             self.mchId,
+		AppId:             self.AppId,
+		NonceStr:          randString(self.NonceLen),
+		SignType:          string(SIGN_TYPE_HMAC_SHA256),
+		OrderId:           orderId,
+		OutOrderNo:        outOrderNo,
+		OutReturnNo:       outReturnNo,
+		ReturnAccountType: string(RECEIVER_TYPE_MERCHANT_ID),
+		ReturnAccount:     returnAccount,
+		ReturnAmount:      returnAmount,
+		Description:       description,
+	}
+
+	sign, err := self.signRequest(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	param.Sign = sign
+
+	return self.postProfitSharingReturn(ctx, self.secureClient, PROFIT_SHARING_RETURN_URL, param)
+}
+
+// 查询分账回退结果，orderId 与 outOrderNo 二选一
+func (self *wechatPay) ProfitSharingReturnQuery(orderId, outOrderNo, outReturnNo string) (*ProfitSharingReturnResponse, error) {
+	return self.ProfitSharingReturnQueryWithContext(context.Background(), orderId, outOrderNo, outReturnNo)
+}
+
+func (self *wechatPay) ProfitSharingReturnQueryWithContext(ctx context.Context, orderId, outOrderNo, outReturnNo string) (*ProfitSharingReturnResponse, error) {
+	if orderId == "" && outOrderNo == "" {
+		return nil, errors.New("order_id or out_order_no is required")
+	}
+
+	param := &profitSharingReturnQueryParam{
+		Mchid:       self.mchId,
+		AppId:       self.AppId,
+		NonceStr:    randString(self.NonceLen),
+		SignType:    string(SIGN_TYPE_HMAC_SHA256),
+		OrderId:     orderId,
+		OutOrderNo:  outOrderNo,
+		OutReturnNo: outReturnNo,
+	}
+
+	sign, err := self.signRequest(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	param.Sign = sign
+
+	return self.postProfitSharingReturn(ctx, self.nonSecureClient, PROFIT_SHARING_RETURN_QUERY_URL, param)
+}
+
+func (self *wechatPay) profitSharingReceiver(ctx context.Context, apiUrl string, receiver *ProfitSharingReceiver) (*ProfitSharingReceiverResponse, error) {
+	receiverJson, err := json.Marshal(receiver)
+	if err != nil {
+		return nil, err
+	}
+
+	param := &profitSharingReceiverParam{
+		Mchid:    self.mchId,
+		AppId:    self.AppId,
+		NonceStr: randString(self.NonceLen),
+		SignType: string(SIGN_TYPE_HMAC_SHA256),
+		Receiver: string(receiverJson),
+	}
+
+	sign, err := self.signRequest(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	param.Sign = sign
+
+	data, _, err := self.post(ctx, self.nonSecureClient, apiUrl, param)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &ProfitSharingReceiverResponse{}
+	if err = xml.Unmarshal(data, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (self *wechatPay) profitSharing(ctx context.Context, apiUrl, transactionId, outOrderNo string, receivers []ProfitSharingAmount) (*ProfitSharingResponse, error) {
+	receiversJson, err := json.Marshal(receivers)
+	if err != nil {
+		return nil, err
+	}
+
+	param := &profitSharingParam{
+		Mchid:         self.mchId,
+		AppId:         self.AppId,
+		NonceStr:      randString(self.NonceLen),
+		SignType:      string(SIGN_TYPE_HMAC_SHA256),
+		TransactionId: transactionId,
+		OutOrderNo:    outOrderNo,
+		Receivers:     string(receiversJson),
+	}
+
+	sign, err := self.signRequest(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	param.Sign = sign
+
+	data, _, err := self.post(ctx, self.secureClient, apiUrl, param)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &ProfitSharingResponse{}
+	if err = xml.Unmarshal(data, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (self *wechatPay) postProfitSharingReturn(ctx context.Context, client *http.Client, apiUrl string, param interface{}) (*ProfitSharingReturnResponse, error) {
+	data, _, err := self.post(ctx, client, apiUrl, param)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &ProfitSharingReturnResponse{}
+	if err = xml.Unmarshal(data, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
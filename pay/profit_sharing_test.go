@@ -0,0 +1,38 @@
+package pay
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func Test_ProfitSharingReceiver_json(t *testing.T) {
+	receiver := &ProfitSharingReceiver{
+		Type:         RECEIVER_TYPE_MERCHANT_ID,
+		Account:      "190001001",
+		Name:         "示例商户全称",
+		RelationType: RELATION_TYPE_STORE_OWNER,
+	}
+
+	data, err := json.Marshal(receiver)
+	if err != nil {
+		t.Fatalf("Marshal return err: %v", err)
+	}
+
+	want := `{"type":"MERCHANT_ID","account":"190001001","name":"示例商户全称","relation_type":"STORE_OWNER"}`
+	if string(data) != want {
+		t.Errorf("Marshal fail. want: %v. get: %v", want, string(data))
+	}
+}
+
+func Test_ProfitSharingReceiverResult_json(t *testing.T) {
+	data := `[{"type":"MERCHANT_ID","account":"190001001","amount":100,"description":"分到商户","result":"SUCCESS","finish_time":"20180608170132"}]`
+
+	var receivers []ProfitSharingReceiverResult
+	if err := json.Unmarshal([]byte(data), &receivers); err != nil {
+		t.Fatalf("Unmarshal return err: %v", err)
+	}
+
+	if len(receivers) != 1 || receivers[0].Amount != 100 || receivers[0].Result != PROFIT_SHARING_RESULT_SUCCESS {
+		t.Errorf("Unmarshal fail. get: %+v", receivers)
+	}
+}
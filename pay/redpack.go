@@ -0,0 +1,279 @@
+package pay
+
+import (
+	"context"
+	"encoding/xml"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+/*
+现金红包，需要证书
+普通红包: https://pay.weixin.qq.com/wiki/doc/api/tools/cash_coupon.php?chapter=13_4&index=3
+裂变红包: https://pay.weixin.qq.com/wiki/doc/api/tools/cash_coupon.php?chapter=13_5&index=4
+查询红包记录: https://pay.weixin.qq.com/wiki/doc/api/tools/cash_coupon.php?chapter=13_6&index=5
+*/
+
+const (
+	SEND_RED_PACK_URL       = "https://api.mch.weixin.qq.com/mmpaymkttransfers/sendredpack"
+	SEND_GROUP_RED_PACK_URL = "https://api.mch.weixin.qq.com/mmpaymkttransfers/sendgroupredpack"
+	GET_RED_PACK_INFO_URL   = "https://api.mch.weixin.qq.com/mmpaymkttransfers/gethbinfo"
+)
+
+// 红包发放场景，发放金额小于1元或大于200元时必填
+type RedPackSceneId string
+
+const (
+	RED_PACK_SCENE_PRODUCT_1 RedPackSceneId = "PRODUCT_1" // 商品促销
+	RED_PACK_SCENE_PRODUCT_2 RedPackSceneId = "PRODUCT_2" // 抽奖
+	RED_PACK_SCENE_PRODUCT_3 RedPackSceneId = "PRODUCT_3" // 虚拟物品兑奖
+	RED_PACK_SCENE_PRODUCT_4 RedPackSceneId = "PRODUCT_4" // 企业内部福利
+	RED_PACK_SCENE_PRODUCT_5 RedPackSceneId = "PRODUCT_5" // 渠道分润
+	RED_PACK_SCENE_PRODUCT_6 RedPackSceneId = "PRODUCT_6" // 保险回馈
+	RED_PACK_SCENE_PRODUCT_7 RedPackSceneId = "PRODUCT_7" // 彩票派奖
+	RED_PACK_SCENE_PRODUCT_8 RedPackSceneId = "PRODUCT_8" // 税务刮奖
+)
+
+// 裂变红包的金额分配方式
+type RedPackAmtType string
+
+const (
+	RED_PACK_AMT_TYPE_ALL_RAND RedPackAmtType = "ALL_RAND" // 全部随机，目前只支持该方式
+)
+
+type RedPackStatus string
+
+const (
+	RED_PACK_STATUS_SENDING   RedPackStatus = "SENDING"   // 发放中
+	RED_PACK_STATUS_SENT      RedPackStatus = "SENT"      // 已发放待领取
+	RED_PACK_STATUS_FAILED    RedPackStatus = "FAILED"    // 发放失败
+	RED_PACK_STATUS_RECEIVED  RedPackStatus = "RECEIVED"  // 已领取
+	RED_PACK_STATUS_RFUND_ING RedPackStatus = "RFUND_ING" // 退款中，微信字段值即为 RFUND_ING
+	RED_PACK_STATUS_REFUND    RedPackStatus = "REFUND"    // 已退款
+)
+
+// 活动信息，用于风控
+type RedPackRiskInfo struct {
+	PostTime      int64  // 用户操作的时间戳
+	Mobile        string // 业务系统账号的手机号
+	DeviceId      string // mac 地址或者设备唯一标识
+	ClientVersion string // 用户操作的客户端版本
+}
+
+// 编码为 posttime=xx&mobile=xx&deviceid=xx&clientversion=xx 后对整个字符串做一次 urlencode，各字段的值不单独编码
+func (info *RedPackRiskInfo) Encode() string {
+	pairs := make([]string, 0, 4)
+	if info.PostTime > 0 {
+		pairs = append(pairs, "posttime="+strconv.FormatInt(info.PostTime, 10))
+	}
+	if info.Mobile != "" {
+		pairs = append(pairs, "mobile="+info.Mobile)
+	}
+	if info.DeviceId != "" {
+		pairs = append(pairs, "deviceid="+info.DeviceId)
+	}
+	if info.ClientVersion != "" {
+		pairs = append(pairs, "clientversion="+info.ClientVersion)
+	}
+
+	return url.QueryEscape(strings.Join(pairs, "&"))
+}
+
+// 发放红包的参数，金额单位为分
+type RedPackRequest struct {
+	MchBillNo   string           // 商户订单号，组成：mch_id+yyyymmdd+10位一天内不能重复的数字
+	SendName    string           // 红包发送者名称
+	ReOpenid    string           // 接受红包的用户 openid，裂变红包中为种子用户
+	TotalAmount int64            // 付款金额
+	TotalNum    int              // 红包发放总人数，普通红包固定为1，裂变红包至少为3
+	AmtType     RedPackAmtType   // 裂变红包的金额分配方式，为空时使用 ALL_RAND
+	Wishing     string           // 红包祝福语
+	ClientIp    string           // 调用接口的机器 ip，仅普通红包使用
+	ActName     string           // 活动名称
+	Remark      string           // 备注信息
+	SceneId     RedPackSceneId   // 发放场景
+	RiskInfo    *RedPackRiskInfo // 活动信息
+}
+
+type redPackParam struct {
+	NonceStr    string `xml:"nonce_str"`
+	Sign        string `xml:"sign"`
+	MchBillNo   string `xml:"mch_billno"`
+	Mchid       string `xml:"mch_id"`
+	WxAppId     string `xml:"wxappid"`
+	SendName    string `xml:"send_name"`
+	ReOpenid    string `xml:"re_openid"`
+	TotalAmount int64  `xml:"total_amount"`
+	TotalNum    int    `xml:"total_num"`
+	AmtType     string `xml:"amt_type"`
+	Wishing     string `xml:"wishing"`
+	ClientIp    string `xml:"client_ip"`
+	ActName     string `xml:"act_name"`
+	Remark      string `xml:"remark"`
+	SceneId     string `xml:"scene_id"`
+	RiskInfo    string `xml:"risk_info"`
+}
+
+type SendRedPackResponse struct {
+	ReturnCode string `xml:"return_code"`
+	ReturnMsg  string `xml:"return_msg"`
+	Sign       string `xml:"sign"`
+	ResultCode string `xml:"result_code"`
+	ErrCode    string `xml:"err_code"`
+	ErrCodeDes string `xml:"err_code_des"`
+
+	MchBillNo   string `xml:"mch_billno"`
+	MchId       string `xml:"mch_id"`
+	WxAppId     string `xml:"wxappid"`
+	ReOpenid    string `xml:"re_openid"`
+	TotalAmount int64  `xml:"total_amount"`
+	SendListId  string `xml:"send_listid"` // 红包订单的微信单号
+}
+
+type redPackInfoParam struct {
+	NonceStr  string `xml:"nonce_str"`
+	Sign      string `xml:"sign"`
+	MchBillNo string `xml:"mch_billno"`
+	Mchid     string `xml:"mch_id"`
+	AppId     string `xml:"appid"`
+	BillType  string `xml:"bill_type"` // 固定值 MCHT，通过商户订单号获取红包信息
+}
+
+type RedPackInfoResponse struct {
+	ReturnCode string `xml:"return_code"`
+	ReturnMsg  string `xml:"return_msg"`
+	ResultCode string `xml:"result_code"`
+	ErrCode    string `xml:"err_code"`
+	ErrCodeDes string `xml:"err_code_des"`
+
+	MchBillNo    string            `xml:"mch_billno"`
+	MchId        string            `xml:"mch_id"`
+	DetailId     string            `xml:"detail_id"` // 红包单号
+	Status       RedPackStatus     `xml:"status"`
+	SendType     string            `xml:"send_type"` // API-通过API接口发放，UPLOAD-通过上传文件方式发放，ACTIVITY-通过活动方式发放
+	HbType       string            `xml:"hb_type"`   // GROUP-裂变红包，NORMAL-普通红包
+	TotalNum     int               `xml:"total_num"`
+	TotalAmount  int64             `xml:"total_amount"`
+	Reason       string            `xml:"reason"` // 发送失败原因
+	SendTime     string            `xml:"send_time"`
+	RefundTime   string            `xml:"refund_time"`
+	RefundAmount int64             `xml:"refund_amount"`
+	Wishing      string            `xml:"wishing"`
+	Remark       string            `xml:"remark"`
+	ActName      string            `xml:"act_name"`
+	HbList       []RedPackReceiver `xml:"hblist>hbinfo"` // 裂变红包的领取列表
+}
+
+type RedPackReceiver struct {
+	Openid  string `xml:"openid"`
+	Amount  int64  `xml:"amount"`
+	RcvTime string `xml:"rcv_time"`
+}
+
+// 发放普通红包
+func (self *wechatPay) SendRedPack(request *RedPackRequest) (*SendRedPackResponse, error) {
+	return self.SendRedPackWithContext(context.Background(), request)
+}
+
+func (self *wechatPay) SendRedPackWithContext(ctx context.Context, request *RedPackRequest) (*SendRedPackResponse, error) {
+	param := self.newRedPackParam(request)
+	param.TotalNum = 1
+
+	return self.sendRedPack(ctx, SEND_RED_PACK_URL, param)
+}
+
+// 发放裂变红包，由种子用户分享给其他用户领取
+func (self *wechatPay) SendGroupRedPack(request *RedPackRequest) (*SendRedPackResponse, error) {
+	return self.SendGroupRedPackWithContext(context.Background(), request)
+}
+
+func (self *wechatPay) SendGroupRedPackWithContext(ctx context.Context, request *RedPackRequest) (*SendRedPackResponse, error) {
+	param := self.newRedPackParam(request)
+	param.ClientIp = ""
+	param.AmtType = string(RED_PACK_AMT_TYPE_ALL_RAND)
+	if request.AmtType != "" {
+		param.AmtType = string(request.AmtType)
+	}
+
+	return self.sendRedPack(ctx, SEND_GROUP_RED_PACK_URL, param)
+}
+
+// 查询红包记录
+func (self *wechatPay) GetRedPackInfo(mchBillNo string) (*RedPackInfoResponse, error) {
+	return self.GetRedPackInfoWithContext(context.Background(), mchBillNo)
+}
+
+func (self *wechatPay) GetRedPackInfoWithContext(ctx context.Context, mchBillNo string) (*RedPackInfoResponse, error) {
+	param := &redPackInfoParam{
+		NonceStr:  randString(self.NonceLen),
+		MchBillNo: mchBillNo,
+		Mchid:     self.mchId,
+		AppId:     self.AppId,
+		BillType:  "MCHT",
+	}
+
+	sign, err := self.signRequest(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	param.Sign = sign
+
+	data, _, err := self.post(ctx, self.secureClient, GET_RED_PACK_INFO_URL, param)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &RedPackInfoResponse{}
+	if err = xml.Unmarshal(data, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (self *wechatPay) newRedPackParam(request *RedPackRequest) *redPackParam {
+	param := &redPackParam{
+		NonceStr:    randString(self.NonceLen),
+		MchBillNo:   request.MchBillNo,
+		Mchid:       self.mchId,
+		WxAppId:     self.AppId,
+		SendName:    request.SendName,
+		ReOpenid:    request.ReOpenid,
+		TotalAmount: request.TotalAmount,
+		TotalNum:    request.TotalNum,
+		Wishing:     request.Wishing,
+		ClientIp:    request.ClientIp,
+		ActName:     request.ActName,
+		Remark:      request.Remark,
+		SceneId:     string(request.SceneId),
+	}
+
+	if request.RiskInfo != nil {
+		param.RiskInfo = request.RiskInfo.Encode()
+	}
+
+	return param
+}
+
+func (self *wechatPay) sendRedPack(ctx context.Context, apiUrl string, param *redPackParam) (*SendRedPackResponse, error) {
+	sign, err := self.signRequest(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	param.Sign = sign
+
+	data, _, err := self.post(ctx, self.secureClient, apiUrl, param)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &SendRedPackResponse{}
+	if err = xml.Unmarshal(data, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,47 @@
+package pay
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+func Test_RedPackRiskInfo_Encode(t *testing.T) {
+	info := &RedPackRiskInfo{
+		PostTime:      1234567890,
+		Mobile:        "13800000000",
+		ClientVersion: "6.5",
+	}
+
+	want := "posttime%3D1234567890%26mobile%3D13800000000%26clientversion%3D6.5"
+	if result := info.Encode(); result != want {
+		t.Errorf("Encode fail. want: %v. get: %v", want, result)
+	}
+
+	// 值中的空格与 & 只编码一次
+	info = &RedPackRiskInfo{DeviceId: "a b&c"}
+	want = "deviceid%3Da+b%26c"
+	if result := info.Encode(); result != want {
+		t.Errorf("Encode should escape values once. want: %v. get: %v", want, result)
+	}
+}
+
+func Test_RedPackInfoResponse_hbList(t *testing.T) {
+	data := []byte(`<xml>
+<return_code><![CDATA[SUCCESS]]></return_code>
+<status><![CDATA[RECEIVED]]></status>
+<hb_type><![CDATA[GROUP]]></hb_type>
+<hblist>
+<hbinfo><openid><![CDATA[o0]]></openid><amount>100</amount><rcv_time><![CDATA[2015-04-21 20:00:00]]></rcv_time></hbinfo>
+<hbinfo><openid><![CDATA[o1]]></openid><amount>200</amount><rcv_time><![CDATA[2015-04-21 20:01:00]]></rcv_time></hbinfo>
+</hblist>
+</xml>`)
+
+	resp := &RedPackInfoResponse{}
+	if err := xml.Unmarshal(data, resp); err != nil {
+		t.Fatalf("Unmarshal return err: %v", err)
+	}
+
+	if resp.Status != RED_PACK_STATUS_RECEIVED || len(resp.HbList) != 2 || resp.HbList[1].Openid != "o1" || resp.HbList[1].Amount != 200 {
+		t.Errorf("Unmarshal fail for hblist. get: %+v", resp)
+	}
+}
@@ -1,10 +1,8 @@
 package pay
 
 import (
-	"bytes"
+	"context"
 	"encoding/xml"
-	"io/ioutil"
-	"net/http"
 )
 
 /*
@@ -21,6 +19,7 @@ type RefundParam struct {
 	Mchid         string `xml:"mch_id"`
 	NonceStr      string `xml:"nonce_str"` //随机串，必填
 	Sign          string `xml:"sign"`
+	SignType      string `xml:"sign_type"`
 	TransactionId string `xml:"transaction_id"`  // 微信订单号，与商户订单号需要二选一填写
 	OutTradeNo    string `xml:"out_trade_no"`    // 商户订单号，与微信订单号需要二选一填写
 	OutRefundNo   string `xml:"out_refund_no"`   // 商户退款单号
@@ -56,11 +55,16 @@ type RefundResponse struct {
 	CashRefundFee       int64  `xml:"cash_refund_fee"`
 }
 
-func (self *wechatPay) Refund(transactionId, outTradeNo, outRefundNo string, orderTotalFee, refundFee int64, notifyUrl, refundDesc string) (*RefundResponse, error) {
+func (self *wechatPay) Refund(transactionId, outTradeNo, outRefundNo string, orderTotalFee, refundFee int64, notifyUrl, refundDesc string, options ...RequestOption) (*RefundResponse, error) {
+	return self.RefundWithContext(context.Background(), transactionId, outTradeNo, outRefundNo, orderTotalFee, refundFee, notifyUrl, refundDesc, options...)
+}
+
+func (self *wechatPay) RefundWithContext(ctx context.Context, transactionId, outTradeNo, outRefundNo string, orderTotalFee, refundFee int64, notifyUrl, refundDesc string, options ...RequestOption) (*RefundResponse, error) {
 	param := &RefundParam{
 		AppId:         self.AppId,
 		Mchid:         self.mchId,
 		NonceStr:      randString(self.NonceLen),
+		SignType:      string(self.requestOption(options).signType),
 		TransactionId: transactionId,
 		OutTradeNo:    outTradeNo,
 		OutRefundNo:   outRefundNo,
@@ -71,29 +75,18 @@ func (self *wechatPay) Refund(transactionId, outTradeNo, outRefundNo string, ord
 		NotifyUrl:     notifyUrl,
 	}
 
-	sign, err := self.Sign(param)
-	if err != nil {
+	if err := validateRefund(param); err != nil {
 		return nil, err
 	}
 
-	param.Sign = sign
-
-	requestBody, err := xml.Marshal(param)
+	sign, err := self.signRequest(ctx, param)
 	if err != nil {
 		return nil, err
 	}
 
-	request, err := http.NewRequest("POST", REFUND_URL, bytes.NewReader(requestBody))
-	if err != nil {
-		return nil, err
-	}
-
-	result, err := self.secureClient.Do(request)
-	if err != nil {
-		return nil, err
-	}
+	param.Sign = sign
 
-	data, err := ioutil.ReadAll(result.Body)
+	data, _, err := self.post(ctx, self.secureClient, REFUND_URL, param)
 	if err != nil {
 		return nil, err
 	}
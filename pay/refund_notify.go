@@ -0,0 +1,112 @@
+package pay
+
+import (
+	"crypto/aes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+)
+
+/*
+退款结果通知
+https://pay.weixin.qq.com/wiki/doc/api/jsapi.php?chapter=9_16&index=10
+*/
+
+type RefundNotifyInfo struct {
+	ReturnCode string `xml:"return_code"`
+	ReturnMsg  string `xml:"return_msg"`
+	AppId      string `xml:"appid"`
+	MchId      string `xml:"mch_id"`
+	NonceStr   string `xml:"nonce_str"`
+	ReqInfo    string `xml:"req_info"` // 加密信息，解密后的内容见 RefundNotifyReqInfo
+
+	RefundNotifyReqInfo `xml:"-"`
+}
+
+// req_info 解密后的退款信息
+type RefundNotifyReqInfo struct {
+	TransactionId       string       `xml:"transaction_id"`
+	OutTradeNo          string       `xml:"out_trade_no"`
+	RefundId            string       `xml:"refund_id"`             // 微信退款单号
+	OutRefundNo         string       `xml:"out_refund_no"`         // 商户退款单号
+	TotalFee            int64        `xml:"total_fee"`             // 订单金额
+	SettlementTotalFee  int64        `xml:"settlement_total_fee"`  // 应结订单金额
+	RefundFee           int64        `xml:"refund_fee"`            // 申请退款金额
+	SettlementRefundFee int64        `xml:"settlement_refund_fee"` // 退款金额=申请退款金额-非充值代金券退款金额
+	RefundStatus        RefundStatus `xml:"refund_status"`         // SUCCESS-退款成功，CHANGE-退款异常，REFUNDCLOSE—退款关闭
+	SuccessTime         string       `xml:"success_time"`          // 退款成功时间，格式为 yyyy-MM-dd HH:mm:ss
+	RefundRecvAccout    string       `xml:"refund_recv_accout"`    // 退款入账账户
+	RefundAccount       string       `xml:"refund_account"`        // 退款资金来源
+	RefundRequestSource string       `xml:"refund_request_source"` // API-接口，VENDOR_PLATFORM-商户平台
+}
+
+// 解析退款结果通知。退款通知没有签名，通过 api 密钥解密 req_info 来确认通知来源
+// 解密方式：对 req_info 做 base64 解码，再以 api 密钥的 md5 小写值为 key 做 AES-256-ECB 解密
+// 报文或解密后的 req_info 无法解析时返回 *FormatError，return_code 为 FAIL 时返回 *ResultError
+func (self *wechatPay) ParseRefundNotify(body []byte) (*RefundNotifyInfo, error) {
+	info := &RefundNotifyInfo{}
+
+	if err := xml.Unmarshal(body, info); err != nil {
+		return nil, &FormatError{Err: err}
+	}
+
+	// return_code 为 FAIL 时通知中没有 req_info，无法确认通知来源
+	if info.ReturnCode != "SUCCESS" {
+		return nil, &ResultError{ReturnCode: info.ReturnCode, ReturnMsg: info.ReturnMsg}
+	}
+
+	plain, err := self.decryptReqInfo(info.ReqInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := xml.Unmarshal(plain, &info.RefundNotifyReqInfo); err != nil {
+		return nil, &FormatError{Err: err}
+	}
+
+	return info, nil
+}
+
+func (self *wechatPay) decryptReqInfo(reqInfo string) ([]byte, error) {
+	encrypted, err := base64.StdEncoding.DecodeString(reqInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher([]byte(md5Str(self.apiSignKey)))
+	if err != nil {
+		return nil, err
+	}
+
+	size := block.BlockSize()
+	if len(encrypted) == 0 || len(encrypted)%size != 0 {
+		return nil, errors.New("req_info is not a multiple of the block size")
+	}
+
+	plain := make([]byte, len(encrypted))
+	for start := 0; start < len(encrypted); start += size {
+		block.Decrypt(plain[start:start+size], encrypted[start:start+size])
+	}
+
+	return pkcs7UnPadding(plain, size)
+}
+
+func pkcs7UnPadding(data []byte, blockSize int) ([]byte, error) {
+	length := len(data)
+	if length == 0 {
+		return nil, errors.New("pkcs7 unpadding on empty data")
+	}
+
+	padding := int(data[length-1])
+	if padding < 1 || padding > blockSize || padding > length {
+		return nil, errors.New("invalid pkcs7 padding")
+	}
+
+	for _, b := range data[length-padding:] {
+		if int(b) != padding {
+			return nil, errors.New("invalid pkcs7 padding")
+		}
+	}
+
+	return data[:length-padding], nil
+}
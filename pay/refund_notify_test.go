@@ -0,0 +1,92 @@
+package pay
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/base64"
+	"testing"
+)
+
+func encryptReqInfo(key string, plain []byte) string {
+	block, _ := aes.NewCipher([]byte(md5Str(key)))
+	size := block.BlockSize()
+
+	padding := size - len(plain)%size
+	plain = append(plain, bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+	encrypted := make([]byte, len(plain))
+	for start := 0; start < len(plain); start += size {
+		block.Encrypt(encrypted[start:start+size], plain[start:start+size])
+	}
+
+	return base64.StdEncoding.EncodeToString(encrypted)
+}
+
+func Test_wechatPay_ParseRefundNotify(t *testing.T) {
+	reqInfo := encryptReqInfo(pay.apiSignKey, []byte(`<root>
+<out_refund_no><![CDATA[r0]]></out_refund_no>
+<out_trade_no><![CDATA[o0]]></out_trade_no>
+<refund_id><![CDATA[w0]]></refund_id>
+<refund_fee><![CDATA[100]]></refund_fee>
+<settlement_refund_fee><![CDATA[90]]></settlement_refund_fee>
+<refund_status><![CDATA[SUCCESS]]></refund_status>
+<success_time><![CDATA[2017-12-15 09:46:01]]></success_time>
+<refund_recv_accout><![CDATA[支付用户的零钱]]></refund_recv_accout>
+<refund_request_source><![CDATA[API]]></refund_request_source>
+</root>`))
+
+	body := []byte("<xml><return_code>SUCCESS</return_code><appid>wx0</appid><req_info><![CDATA[" + reqInfo + "]]></req_info></xml>")
+
+	info, err := pay.ParseRefundNotify(body)
+	if err != nil {
+		t.Fatalf("ParseRefundNotify return err: %v", err)
+	}
+
+	if info.AppId != "wx0" || info.OutRefundNo != "r0" || info.OutTradeNo != "o0" || info.RefundId != "w0" {
+		t.Errorf("ParseRefundNotify fail for ids. get: %+v", info)
+	}
+
+	if info.RefundFee != 100 || info.SettlementRefundFee != 90 {
+		t.Errorf("ParseRefundNotify fail for fee. get: %+v", info)
+	}
+
+	if info.RefundStatus != REFUND_STATUS_SUCCESS || info.SuccessTime != "2017-12-15 09:46:01" ||
+		info.RefundRecvAccout != "支付用户的零钱" || info.RefundRequestSource != "API" {
+		t.Errorf("ParseRefundNotify fail for status. get: %+v", info)
+	}
+
+	wrong := &wechatPay{apiSignKey: "wrong-key"}
+	if _, err := wrong.ParseRefundNotify(body); err == nil {
+		t.Errorf("ParseRefundNotify should fail with wrong key")
+	}
+
+	failBody := []byte("<xml><return_code>FAIL</return_code><return_msg>参数错误</return_msg></xml>")
+	if info, err := pay.ParseRefundNotify(failBody); info != nil || err == nil {
+		t.Errorf("ParseRefundNotify should fail for return_code FAIL. get: %+v", info)
+	} else if _, ok := err.(*ResultError); !ok {
+		t.Errorf("ParseRefundNotify should return *ResultError. get: %T", err)
+	}
+	if _, err := pay.ParseRefundNotify([]byte("<xml><return_code>SUCCESS")); err == nil {
+		t.Errorf("ParseRefundNotify should fail for malformed xml")
+	} else if _, ok := err.(*FormatError); !ok {
+		t.Errorf("ParseRefundNotify should return *FormatError for malformed xml. get: %T", err)
+	}
+
+	malformed := encryptReqInfo(pay.apiSignKey, []byte("<root><out_refund_no>r0"))
+	if _, err := pay.ParseRefundNotify([]byte("<xml><return_code>SUCCESS</return_code><req_info>" + malformed + "</req_info></xml>")); err == nil {
+		t.Errorf("ParseRefundNotify should fail for malformed req_info")
+	} else if _, ok := err.(*FormatError); !ok {
+		t.Errorf("ParseRefundNotify should return *FormatError for malformed req_info. get: %T", err)
+	}
+}
+
+func Test_pkcs7UnPadding(t *testing.T) {
+	result, err := pkcs7UnPadding([]byte{'a', 'b', 3, 3, 3}, 16)
+	if err != nil || string(result) != "ab" {
+		t.Errorf("pkcs7UnPadding fail. get: %v, %v", result, err)
+	}
+
+	if _, err := pkcs7UnPadding([]byte{'a', 'b', 1, 2, 3}, 16); err == nil {
+		t.Errorf("pkcs7UnPadding should fail when padding bytes mismatch")
+	}
+}
@@ -0,0 +1,184 @@
+package pay
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"strconv"
+)
+
+/*
+微信支付查询退款接口
+https://pay.weixin.qq.com/wiki/doc/api/jsapi.php?chapter=9_5
+*/
+
+const (
+	REFUND_QUERY_URL = "https://api.mch.weixin.qq.com/pay/refundquery"
+)
+
+type RefundStatus string
+
+const (
+	REFUND_STATUS_SUCCESS     RefundStatus = "SUCCESS"     // 退款成功
+	REFUND_STATUS_REFUNDCLOSE RefundStatus = "REFUNDCLOSE" // 退款关闭
+	REFUND_STATUS_PROCESSING  RefundStatus = "PROCESSING"  // 退款处理中
+	REFUND_STATUS_CHANGE      RefundStatus = "CHANGE"      // 退款异常，需要到商户平台手动处理
+)
+
+type RefundQueryParam struct {
+	AppId         string `xml:"appid"`
+	Mchid         string `xml:"mch_id"`
+	NonceStr      string `xml:"nonce_str"`
+	Sign          string `xml:"sign"`
+	SignType      string `xml:"sign_type"`
+	TransactionId string `xml:"transaction_id"` // 微信订单号，四个单号四选一
+	OutTradeNo    string `xml:"out_trade_no"`   // 商户订单号
+	OutRefundNo   string `xml:"out_refund_no"`  // 商户退款单号
+	RefundId      string `xml:"refund_id"`      // 微信退款单号
+	Offset        string `xml:"offset"`         // 偏移量，订单退款次数超过10次时用于分页查询
+}
+
+type RefundQueryResponse struct {
+	ReturnCode string `xml:"return_code"`
+	ReturnMsg  string `xml:"return_msg"`
+
+	ResultCode string `xml:"result_code"`
+	ErrCode    string `xml:"err_code"`
+	ErrCodeDes string `xml:"err_code_des"`
+
+	AppId    string `xml:"appid"`
+	MchId    string `xml:"mch_id"`
+	NonceStr string `xml:"nonce_str"`
+	Sign     string `xml:"sign"`
+
+	TotalRefundCount   int    `xml:"total_refund_count"` // 订单总共已发生的部分退款次数，使用 offset 时返回
+	TransactionId      string `xml:"transaction_id"`
+	OutTradeNo         string `xml:"out_trade_no"`
+	TotalFee           int64  `xml:"total_fee"`
+	SettlementTotalFee int64  `xml:"settlement_total_fee"`
+	FeeType            string `xml:"fee_type"`
+	CashFee            int64  `xml:"cash_fee"`
+	RefundCount        int    `xml:"refund_count"` // 本次返回的退款笔数
+
+	Refunds []RefundRecord `xml:"-"` // 由 out_refund_no_$n、refund_status_$n 等字段解析得到
+}
+
+// 单笔退款记录
+type RefundRecord struct {
+	OutRefundNo         string       // 商户退款单号
+	RefundId            string       // 微信退款单号
+	RefundChannel       string       // ORIGINAL—原路退款，BALANCE—退回到余额
+	RefundFee           int64        // 申请退款金额
+	SettlementRefundFee int64        // 退款金额=申请退款金额-非充值代金券退款金额
+	CouponRefundFee     int64        // 代金券退款总金额
+	Coupons             []Coupon     // 退款代金券
+	RefundStatus        RefundStatus // 退款状态
+	RefundAccount       string       // 退款资金来源
+	RefundRecvAccout    string       // 退款入账账户，微信字段名即为 accout
+	RefundSuccessTime   string       // 退款成功时间，格式为 yyyy-MM-dd HH:mm:ss
+}
+
+// 查询退款，transactionId、outTradeNo、outRefundNo、refundId 四选一，优先级为 refundId > outRefundNo > transactionId > outTradeNo
+// 订单退款次数超过10次时，通过 offset 分页查询，每页最多返回10笔
+func (self *wechatPay) RefundQuery(transactionId, outTradeNo, outRefundNo, refundId string, offset int, options ...RequestOption) (*RefundQueryResponse, error) {
+	return self.RefundQueryWithContext(context.Background(), transactionId, outTradeNo, outRefundNo, refundId, offset, options...)
+}
+
+func (self *wechatPay) RefundQueryWithContext(ctx context.Context, transactionId, outTradeNo, outRefundNo, refundId string, offset int, options ...RequestOption) (*RefundQueryResponse, error) {
+	if transactionId == "" && outTradeNo == "" && outRefundNo == "" && refundId == "" {
+		return nil, errors.New("one of transaction_id, out_trade_no, out_refund_no and refund_id is required")
+	}
+
+	param := &RefundQueryParam{
+		AppId:         self.AppId,
+		Mchid:         self.mchId,
+		NonceStr:      randString(self.NonceLen),
+		SignType:      string(self.requestOption(options).signType),
+		TransactionId: transactionId,
+		OutTradeNo:    outTradeNo,
+		OutRefundNo:   outRefundNo,
+		RefundId:      refundId,
+	}
+
+	if offset > 0 {
+		param.Offset = strconv.Itoa(offset)
+	}
+
+	sign, err := self.signRequest(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	param.Sign = sign
+
+	data, values, err := self.post(ctx, self.nonSecureClient, REFUND_QUERY_URL, param)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &RefundQueryResponse{}
+	if err = xml.Unmarshal(data, resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Refunds, err = parseRefundRecords(values, resp.RefundCount); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// 解析 $n 下标的退款记录，以及 $n_$m 下标的退款代金券，下标均从 0 开始
+func parseRefundRecords(values map[string]string, count int) ([]RefundRecord, error) {
+	records := make([]RefundRecord, 0, count)
+
+	for i := 0; i < count; i++ {
+		n := strconv.Itoa(i)
+
+		record := RefundRecord{
+			OutRefundNo:       values["out_refund_no_"+n],
+			RefundId:          values["refund_id_"+n],
+			RefundChannel:     values["refund_channel_"+n],
+			RefundStatus:      RefundStatus(values["refund_status_"+n]),
+			RefundAccount:     values["refund_account_"+n],
+			RefundRecvAccout:  values["refund_recv_accout_"+n],
+			RefundSuccessTime: values["refund_success_time_"+n],
+		}
+
+		var err error
+		if record.RefundFee, err = int64Value(values, "refund_fee_"+n); err != nil {
+			return nil, err
+		}
+		if record.SettlementRefundFee, err = int64Value(values, "settlement_refund_fee_"+n); err != nil {
+			return nil, err
+		}
+		if record.CouponRefundFee, err = int64Value(values, "coupon_refund_fee_"+n); err != nil {
+			return nil, err
+		}
+
+		couponCount, err := int64Value(values, "coupon_refund_count_"+n)
+		if err != nil {
+			return nil, err
+		}
+
+		record.Coupons = make([]Coupon, 0, couponCount)
+		for j := 0; j < int(couponCount); j++ {
+			m := n + "_" + strconv.Itoa(j)
+
+			fee, err := int64Value(values, "coupon_refund_fee_"+m)
+			if err != nil {
+				return nil, err
+			}
+
+			record.Coupons = append(record.Coupons, Coupon{
+				Id:   values["coupon_refund_id_"+m],
+				Type: values["coupon_type_"+m],
+				Fee:  fee,
+			})
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,59 @@
+package pay
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseRefundRecords(t *testing.T) {
+	values, err := parseXMLMap([]byte(`<xml>
+<refund_count>2</refund_count>
+<out_refund_no_0><![CDATA[r0]]></out_refund_no_0>
+<refund_id_0><![CDATA[w0]]></refund_id_0>
+<refund_fee_0>100</refund_fee_0>
+<settlement_refund_fee_0>90</settlement_refund_fee_0>
+<coupon_refund_fee_0>10</coupon_refund_fee_0>
+<coupon_refund_count_0>1</coupon_refund_count_0>
+<coupon_refund_id_0_0><![CDATA[c0]]></coupon_refund_id_0_0>
+<coupon_type_0_0><![CDATA[NO_CASH]]></coupon_type_0_0>
+<coupon_refund_fee_0_0>10</coupon_refund_fee_0_0>
+<refund_status_0><![CDATA[SUCCESS]]></refund_status_0>
+<refund_recv_accout_0><![CDATA[支付用户的零钱]]></refund_recv_accout_0>
+<refund_success_time_0><![CDATA[2017-12-15 09:46:01]]></refund_success_time_0>
+<out_refund_no_1><![CDATA[r1]]></out_refund_no_1>
+<refund_fee_1>50</refund_fee_1>
+<refund_status_1><![CDATA[PROCESSING]]></refund_status_1>
+</xml>`))
+	if err != nil {
+		t.Fatalf("parseXMLMap return err: %v", err)
+	}
+
+	records, err := parseRefundRecords(values, 2)
+	if err != nil {
+		t.Fatalf("parseRefundRecords return err: %v", err)
+	}
+
+	want := []RefundRecord{
+		{
+			OutRefundNo:         "r0",
+			RefundId:            "w0",
+			RefundFee:           100,
+			SettlementRefundFee: 90,
+			CouponRefundFee:     10,
+			Coupons:             []Coupon{{Id: "c0", Type: "NO_CASH", Fee: 10}},
+			RefundStatus:        REFUND_STATUS_SUCCESS,
+			RefundRecvAccout:    "支付用户的零钱",
+			RefundSuccessTime:   "2017-12-15 09:46:01",
+		},
+		{
+			OutRefundNo:  "r1",
+			RefundFee:    50,
+			Coupons:      []Coupon{},
+			RefundStatus: REFUND_STATUS_PROCESSING,
+		},
+	}
+
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("parseRefundRecords fail. want: %+v. get: %+v", want, records)
+	}
+}
@@ -0,0 +1,92 @@
+package pay
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+)
+
+// 返回中不带签名的接口，按接口文档，企业付款、付款到银行卡、获取 RSA 公钥以及现金红包接口的返回不含 sign 字段
+var unsignedResponseUrls = map[string]bool{
+	TRANSFER_URL:            true,
+	GET_TRANSFER_INFO_URL:   true,
+	PAY_BANK_URL:            true,
+	QUERY_BANK_URL:          true,
+	GET_PUBLIC_KEY_URL:      true,
+	SEND_RED_PACK_URL:       true,
+	SEND_GROUP_RED_PACK_URL: true,
+	GET_RED_PACK_INFO_URL:   true,
+}
+
+// 发送请求并校验返回内容，返回原始报文以及解析后的 key-value 字段
+// return_code 为 SUCCESS 时使用返回中的全部字段验签，返回缺少签名或验签失败时返回 *SignError
+// return_code 为 FAIL 的返回不带签名，unsignedResponseUrls 中的接口只在返回携带签名时验签
+// return_code 或 result_code 不为 SUCCESS 时返回 *ResultError，同时返回报文，用于读取 recall 等失败时的附加字段
+func (self *wechatPay) post(ctx context.Context, client *http.Client, url string, param interface{}) ([]byte, map[string]string, error) {
+	data, err := self.doPost(ctx, client, url, param)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values, err := parseXMLMap(data)
+	if err != nil {
+		return nil, nil, &FormatError{Err: err}
+	}
+
+	if (values["return_code"] == "SUCCESS" && !unsignedResponseUrls[url]) || values["sign"] != "" {
+		if err := self.verifyMapSign(values, paramSignType(param)); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := checkResult(values); err != nil {
+		return data, values, err
+	}
+
+	return data, values, nil
+}
+
+// 将请求参数编码为 xml 并发送到微信接口，返回原始的返回内容，ctx 取消或超时时请求随之中断
+func (self *wechatPay) doPost(ctx context.Context, client *http.Client, url string, param interface{}) ([]byte, error) {
+	result, err := self.doRequest(ctx, client, url, param)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Body.Close()
+
+	return ioutil.ReadAll(result.Body)
+}
+
+// 将请求参数编码为 xml 并发送到微信接口，由调用方读取并关闭返回的 Body，用于账单等需要流式读取的接口
+func (self *wechatPay) doRequest(ctx context.Context, client *http.Client, url string, param interface{}) (*http.Response, error) {
+	if client == nil {
+		return nil, errors.New("need create secure wechat with CA")
+	}
+
+	body, err := xml.Marshal(param)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, "POST", self.apiUrl(url), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Do(request)
+}
+
+// 请求过程中的网络错误，如连接失败、超时、读取返回时连接中断，可以重新请求
+func isTransportError(err error) bool {
+	if err == io.ErrUnexpectedEOF {
+		return true
+	}
+
+	_, ok := err.(net.Error)
+	return ok
+}
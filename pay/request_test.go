@@ -0,0 +1,67 @@
+package pay
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_wechatPay_post(t *testing.T) {
+	body := signedNotifyBody(map[string]string{
+		"return_code": "SUCCESS",
+		"result_code": "SUCCESS",
+		"prepay_id":   "p0",
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	_, values, err := pay.post(context.Background(), server.Client(), server.URL, &testSignTypeParam{S: "test"})
+	if err != nil {
+		t.Fatalf("post return err: %v", err)
+	}
+	if values["prepay_id"] != "p0" {
+		t.Errorf("post fail for values. get: %v", values)
+	}
+
+	body = []byte(strings.Replace(string(body), "p0", "p1", 1))
+	if _, _, err := pay.post(context.Background(), server.Client(), server.URL, &testSignTypeParam{S: "test"}); err == nil {
+		t.Errorf("post should fail for forged response")
+	} else if _, ok := err.(*SignError); !ok {
+		t.Errorf("post should return *SignError. get: %T", err)
+	}
+
+	body = []byte("<xml><return_code><![CDATA[SUCCESS]]></return_code><result_code><![CDATA[SUCCESS]]></result_code><prepay_id><![CDATA[p1]]></prepay_id></xml>")
+	if _, _, err := pay.post(context.Background(), server.Client(), server.URL, &testSignTypeParam{S: "test"}); err == nil {
+		t.Errorf("post should fail for response without sign")
+	} else if _, ok := err.(*SignError); !ok {
+		t.Errorf("post should return *SignError for response without sign. get: %T", err)
+	}
+
+	body = []byte("<xml><return_code><![CDATA[FAIL]]></return_code><return_msg><![CDATA[签名失败]]></return_msg></xml>")
+	if _, _, err := pay.post(context.Background(), server.Client(), server.URL, &testSignTypeParam{S: "test"}); err == nil {
+		t.Errorf("post should fail for return_code FAIL")
+	} else if _, ok := err.(*ResultError); !ok {
+		t.Errorf("post should return *ResultError for return_code FAIL. get: %T", err)
+	}
+}
+
+func Test_wechatPay_post_cancel(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, _, err := pay.post(ctx, server.Client(), server.URL, &testSignTypeParam{S: "test"}); err == nil {
+		t.Errorf("post should fail when ctx canceled")
+	}
+}
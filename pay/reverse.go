@@ -0,0 +1,150 @@
+package pay
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"time"
+)
+
+/*
+撤销订单，需要证书，仅用于付款码支付
+https://pay.weixin.qq.com/wiki/doc/api/micropay.php?chapter=9_11&index=3
+*/
+
+const (
+	REVERSE_URL = "https://api.mch.weixin.qq.com/secapi/pay/reverse"
+
+	REVERSE_MAX_TIMES      = 5               // 撤销接口返回 recall=Y 或系统错误时的最多调用次数
+	REVERSE_RETRY_INTERVAL = 3 * time.Second // 重新调用撤销接口的间隔
+)
+
+type ReverseStatus string
+
+const (
+	REVERSE_STATUS_REVERSED   ReverseStatus = "REVERSED"   // 撤销成功，用户已支付的款项会原路退回
+	REVERSE_STATUS_NEED_RETRY ReverseStatus = "NEED_RETRY" // 多次调用后仍需重试，需要稍后再次撤销
+	REVERSE_STATUS_FAILED     ReverseStatus = "FAILED"     // 无法撤销，如超过撤销期限或订单状态错误
+)
+
+type ReverseParam struct {
+	AppId         string `xml:"appid"`
+	Mchid         string `xml:"mch_id"`
+	TransactionId string `xml:"transaction_id"` // 微信订单号，与商户订单号需要二选一填写
+	OutTradeNo    string `xml:"out_trade_no"`   // 商户订单号，与微信订单号需要二选一填写
+	NonceStr      string `xml:"nonce_str"`
+	Sign          string `xml:"sign"`
+	SignType      string `xml:"sign_type"`
+}
+
+type ReverseResponse struct {
+	ReturnCode string `xml:"return_code"`
+	ReturnMsg  string `xml:"return_msg"`
+	AppId      string `xml:"appid"`
+	MchId      string `xml:"mch_id"`
+	NonceStr   string `xml:"nonce_str"`
+	Sign       string `xml:"sign"`
+	ResultCode string `xml:"result_code"`
+	ErrCode    string `xml:"err_code"`
+	ErrCodeDes string `xml:"err_code_des"`
+
+	Recall string `xml:"recall"` // Y-需要继续调用撤销，N-不需要继续调用撤销
+}
+
+type ReverseResult struct {
+	Status   ReverseStatus
+	Response *ReverseResponse // 撤销成功时的返回
+	Reason   error            // 未能撤销的原因
+}
+
+// 撤销订单，transactionId 与 outTradeNo 二选一
+// 微信返回 recall=Y、可重试的错误码或网络错误时，按 REVERSE_RETRY_INTERVAL 间隔重新调用，最多调用 REVERSE_MAX_TIMES 次
+// 其他错误直接返回，ctx 取消时停止等待并返回 ctx.Err()
+func (self *wechatPay) Reverse(transactionId, outTradeNo string, options ...RequestOption) (*ReverseResult, error) {
+	return self.ReverseWithContext(context.Background(), transactionId, outTradeNo, options...)
+}
+
+func (self *wechatPay) ReverseWithContext(ctx context.Context, transactionId, outTradeNo string, options ...RequestOption) (*ReverseResult, error) {
+	if transactionId == "" && outTradeNo == "" {
+		return nil, errors.New("transaction_id or out_trade_no is required")
+	}
+
+	var reason error
+	for i := 0; i < REVERSE_MAX_TIMES; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(REVERSE_RETRY_INTERVAL):
+			}
+		}
+
+		resp, err := self.reverse(ctx, transactionId, outTradeNo, self.requestOption(options).signType)
+		if err == nil {
+			if resp.Recall == "Y" {
+				reason = errors.New("reverse need recall")
+				continue
+			}
+
+			return &ReverseResult{Status: REVERSE_STATUS_REVERSED, Response: resp}, nil
+		}
+
+		reason = err
+		if e, ok := err.(*ResultError); ok {
+			if !needReverseRecall(e, resp) {
+				return &ReverseResult{Status: REVERSE_STATUS_FAILED, Reason: e}, nil
+			}
+			continue
+		}
+
+		// 只重试网络错误，验签失败、报文错误、缺少证书等错误重试也不会成功
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if !isTransportError(err) {
+			return nil, err
+		}
+	}
+
+	return &ReverseResult{Status: REVERSE_STATUS_NEED_RETRY, Reason: reason}, nil
+}
+
+// 业务失败时，recall=Y 或可重试的错误需要重新调用撤销
+func needReverseRecall(e *ResultError, resp *ReverseResponse) bool {
+	if resp != nil && resp.Recall == "Y" {
+		return true
+	}
+
+	return e.Retryable()
+}
+
+// 调用一次撤销订单接口，业务失败时同时返回 *ReverseResponse 与 *ResultError
+func (self *wechatPay) reverse(ctx context.Context, transactionId, outTradeNo string, signType SignType) (*ReverseResponse, error) {
+	param := &ReverseParam{
+		AppId:         self.AppId,
+		Mchid:         self.mchId,
+		TransactionId: transactionId,
+		OutTradeNo:    outTradeNo,
+		NonceStr:      randString(self.NonceLen),
+		SignType:      string(signType),
+	}
+
+	sign, err := self.signRequest(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	param.Sign = sign
+
+	data, _, err := self.post(ctx, self.secureClient, REVERSE_URL, param)
+	if data == nil {
+		return nil, err
+	}
+
+	resp := &ReverseResponse{}
+	if e := xml.Unmarshal(data, resp); e != nil {
+		return nil, e
+	}
+
+	return resp, err
+}
@@ -0,0 +1,54 @@
+package pay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func Test_wechatPay_Reverse_permanentError(t *testing.T) {
+	requests := 0
+	client := &wechatPay{
+		apiSignKey: pay.apiSignKey,
+		NonceLen:   16,
+		signType:   SIGN_TYPE_MD5,
+		secureClient: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			requests++
+			body := `<xml><return_code><![CDATA[SUCCESS]]></return_code><result_code><![CDATA[SUCCESS]]></result_code><sign><![CDATA[FORGED]]></sign></xml>`
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(body)), Header: http.Header{}}, nil
+		})},
+	}
+
+	start := time.Now()
+	if _, err := client.Reverse("", "o0"); err == nil {
+		t.Errorf("Reverse should fail for forged response")
+	} else if _, ok := err.(*SignError); !ok {
+		t.Errorf("Reverse should return *SignError. get: %v", err)
+	}
+	if requests != 1 || time.Since(start) >= REVERSE_RETRY_INTERVAL {
+		t.Errorf("Reverse should not retry *SignError. requests: %v", requests)
+	}
+
+	client.secureClient = nil
+	if _, err := client.Reverse("", "o0"); err == nil {
+		t.Errorf("Reverse should fail without secure client")
+	}
+}
+
+func Test_isTransportError(t *testing.T) {
+	if !isTransportError(&url.Error{Op: "Post", URL: REVERSE_URL, Err: errors.New("connection refused")}) {
+		t.Errorf("isTransportError should be true for *url.Error")
+	}
+	if !isTransportError(io.ErrUnexpectedEOF) {
+		t.Errorf("isTransportError should be true for io.ErrUnexpectedEOF")
+	}
+	if isTransportError(&SignError{}) || isTransportError(&FormatError{}) || isTransportError(context.Canceled) {
+		t.Errorf("isTransportError should be false for other errors")
+	}
+}
@@ -0,0 +1,150 @@
+package pay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+/*
+仿真测试系统，参见: https://pay.weixin.qq.com/wiki/doc/api/tools/sp_project.php?chapter=23_1
+仿真测试时所有接口地址在域名后增加 /sandboxnew 路径，并使用通过 getsignkey 接口获取的仿真测试密钥签名
+仿真测试系统只支持 MD5 签名
+*/
+
+const (
+	API_HOST                 = "https://api.mch.weixin.qq.com/"
+	SANDBOX_API_HOST         = "https://api.mch.weixin.qq.com/sandboxnew/"
+	SANDBOX_GET_SIGN_KEY_URL = "https://api.mch.weixin.qq.com/sandboxnew/pay/getsignkey"
+)
+
+var (
+	ErrSandboxSignKeyNotLoaded = errors.New("sandbox sign key not loaded, call LoadSandboxSignKey first") // 仿真测试密钥尚未获取
+)
+
+// 使用仿真测试系统，所有接口地址改为仿真测试地址，首次调用接口时使用请求的 ctx 获取并缓存仿真测试密钥
+// Sign、ParseNotifyInfo、NativeBizPayUrl、JSAPIPayParams 等不发起请求的方法不会获取密钥，需要在启动时调用 LoadSandboxSignKey
+// NotifyHandler、RefundNotifyHandler、NativeHandler 在解析回调前会使用请求的 ctx 获取密钥
+// 仿真测试系统只支持 MD5 签名，使用 HMAC-SHA256 签名时返回错误
+func WithSandbox() Option {
+	return func(pay *wechatPay) {
+		pay.sandbox = true
+		pay.sandboxFetch = make(chan struct{}, 1)
+	}
+}
+
+type sandboxSignKeyParam struct {
+	Mchid    string `xml:"mch_id"`
+	NonceStr string `xml:"nonce_str"`
+	Sign     string `xml:"sign"`
+}
+
+// 仿真测试时将正式接口地址改写为仿真测试地址
+func (self *wechatPay) apiUrl(url string) string {
+	if !self.sandbox || !strings.HasPrefix(url, API_HOST) || strings.HasPrefix(url, SANDBOX_API_HOST) {
+		return url
+	}
+
+	return SANDBOX_API_HOST + strings.TrimPrefix(url, API_HOST)
+}
+
+// 签名使用的密钥，仿真测试时为仿真测试密钥，尚未获取时为空
+func (self *wechatPay) signKey() string {
+	if !self.sandbox {
+		return self.apiSignKey
+	}
+
+	self.sandboxLock.RLock()
+	defer self.sandboxLock.RUnlock()
+
+	return self.sandboxSignKey
+}
+
+// 仿真测试时检查签名条件，只支持 MD5，并且需要已获取仿真测试密钥
+func (self *wechatPay) checkSandboxSign(signType SignType) error {
+	if !self.sandbox {
+		return nil
+	}
+
+	if signType != "" && signType != SIGN_TYPE_MD5 {
+		return errors.New(fmt.Sprintf("sandbox only supports MD5 sign. get: %v", signType))
+	}
+
+	if self.signKey() == "" {
+		return ErrSandboxSignKeyNotLoaded
+	}
+
+	return nil
+}
+
+// 仿真测试时获取并缓存仿真测试密钥，已获取或未使用仿真测试系统时直接返回
+// 进程启动后需要先调用，Sign、ParseNotifyInfo 等不发起请求的方法才能使用仿真测试密钥
+func (self *wechatPay) LoadSandboxSignKey(ctx context.Context) error {
+	return self.loadSandboxSignKey(ctx)
+}
+
+// 发送请求前签名，仿真测试时先使用请求的 ctx 获取仿真测试密钥
+func (self *wechatPay) signRequest(ctx context.Context, param interface{}) (string, error) {
+	if err := self.loadSandboxSignKey(ctx); err != nil {
+		return "", err
+	}
+
+	return self.Sign(param)
+}
+
+// 仿真测试时获取并缓存仿真测试密钥，获取失败时下次请求会重新获取
+// 同一时间只有一个请求获取密钥，其他请求等待获取完成或 ctx 取消
+func (self *wechatPay) loadSandboxSignKey(ctx context.Context) error {
+	if !self.sandbox || self.signKey() != "" {
+		return nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	select {
+	case self.sandboxFetch <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-self.sandboxFetch }()
+
+	if self.signKey() != "" {
+		return nil
+	}
+
+	param := &sandboxSignKeyParam{
+		Mchid:    self.mchId,
+		NonceStr: randString(self.NonceLen),
+	}
+
+	// getsignkey 接口使用正式密钥签名
+	param.Sign = strings.ToUpper(md5Str("mch_id=" + param.Mchid + "&nonce_str=" + param.NonceStr + "&key=" + self.apiSignKey))
+
+	data, err := self.doPost(ctx, self.nonSecureClient, SANDBOX_GET_SIGN_KEY_URL, param)
+	if err != nil {
+		return err
+	}
+
+	values, err := parseXMLMap(data)
+	if err != nil {
+		return &FormatError{Err: err}
+	}
+
+	if err := checkResult(values); err != nil {
+		return err
+	}
+
+	key := values["sandbox_signkey"]
+	if key == "" {
+		return errors.New(fmt.Sprintf("get sandbox sign key fail. response: %v", string(data)))
+	}
+
+	self.sandboxLock.Lock()
+	self.sandboxSignKey = key
+	self.sandboxLock.Unlock()
+
+	return nil
+}
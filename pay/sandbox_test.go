@@ -0,0 +1,123 @@
+package pay
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type roundTripFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func Test_wechatPay_apiUrl(t *testing.T) {
+	sandbox := &wechatPay{sandbox: true}
+
+	urls := map[string]string{
+		UNIFIED_ORDER_URL:        "https://api.mch.weixin.qq.com/sandboxnew/pay/unifiedorder",
+		REFUND_URL:               "https://api.mch.weixin.qq.com/sandboxnew/secapi/pay/refund",
+		SANDBOX_GET_SIGN_KEY_URL: SANDBOX_GET_SIGN_KEY_URL,
+	}
+	for url, want := range urls {
+		if result := sandbox.apiUrl(url); result != want {
+			t.Errorf("apiUrl fail for sandbox. want: %v. get: %v", want, result)
+		}
+		if result := pay.apiUrl(url); result != url {
+			t.Errorf("apiUrl should not change url without sandbox. get: %v", result)
+		}
+	}
+}
+
+func Test_wechatPay_sandboxSignKey(t *testing.T) {
+	requests := 0
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		requests++
+		if r.URL.String() != SANDBOX_GET_SIGN_KEY_URL {
+			t.Errorf("request wrong url: %v", r.URL)
+		}
+
+		body := `<xml><return_code><![CDATA[SUCCESS]]></return_code><sandbox_signkey><![CDATA[sandbox-key]]></sandbox_signkey></xml>`
+		return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(body)), Header: http.Header{}}, nil
+	})}
+
+	sandbox := &wechatPay{
+		mchId:           "10000100",
+		apiSignKey:      "test-Sign-key",
+		NonceLen:        16,
+		nonSecureClient: client,
+	}
+	WithSandbox()(sandbox)
+
+	if _, err := sandbox.Sign(param); err != ErrSandboxSignKeyNotLoaded {
+		t.Errorf("Sign should not fetch sandbox key. get: %v", err)
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := sandbox.signRequest(canceled, param); err == nil {
+		t.Errorf("signRequest should fail when ctx canceled")
+	}
+
+	for i := 0; i < 2; i++ {
+		sign, err := sandbox.signRequest(context.Background(), param)
+		if err != nil {
+			t.Fatalf("signRequest return err: %v", err)
+		}
+
+		content, _ := pay.genContentStr(param)
+		want := strings.ToUpper(md5Str(strings.TrimSuffix(content, "test-Sign-key") + "sandbox-key"))
+		if sign != want {
+			t.Errorf("Sign should use sandbox key. want: %v. get: %v", want, sign)
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("sandbox sign key should be cached. requests: %v", requests)
+	}
+
+	if _, err := sandbox.Sign(&testSignTypeParam{S: "test", SignType: string(SIGN_TYPE_HMAC_SHA256)}); err == nil {
+		t.Errorf("Sign should reject HMAC-SHA256 in sandbox")
+	}
+}
+
+func Test_NotifyHandler_sandboxSignKey(t *testing.T) {
+	sandbox := &wechatPay{
+		mchId:      "10000100",
+		apiSignKey: "test-Sign-key",
+		NonceLen:   16,
+		nonSecureClient: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			body := `<xml><return_code><![CDATA[SUCCESS]]></return_code><sandbox_signkey><![CDATA[sandbox-key]]></sandbox_signkey></xml>`
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(body)), Header: http.Header{}}, nil
+		})},
+	}
+	WithSandbox()(sandbox)
+
+	values := map[string]string{"return_code": "SUCCESS", "result_code": "SUCCESS", "out_trade_no": "o0"}
+	content := sandbox.genMapContentStr(values)
+	values["sign"] = strings.ToUpper(md5Str(content + "sandbox-key"))
+
+	body := "<xml>"
+	for name, value := range values {
+		body += "<" + name + "><![CDATA[" + value + "]]></" + name + ">"
+	}
+	body += "</xml>"
+
+	if _, err := sandbox.ParseNotifyInfo([]byte(body)); err != ErrSandboxSignKeyNotLoaded {
+		t.Errorf("ParseNotifyInfo should not fetch sandbox key. get: %v", err)
+	}
+
+	handled := false
+	handler := NewNotifyHandler(sandbox, func(info *NotifyInfo) error {
+		handled = true
+		return nil
+	})
+
+	if reply := serveTestNotify(t, handler, []byte(body)); reply.ReturnCode != "SUCCESS" || !handled {
+		t.Errorf("NotifyHandler should load sandbox key before parsing. get: %+v", reply)
+	}
+}
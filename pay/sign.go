@@ -10,35 +10,181 @@ import (
 
 	"errors"
 
+	"crypto/hmac"
 	"crypto/md5"
+	"crypto/sha256"
+)
+
+type SignType string
+
+const (
+	SIGN_TYPE_MD5         SignType = "MD5"
+	SIGN_TYPE_HMAC_SHA256 SignType = "HMAC-SHA256"
 )
 
 /*
 微信签名规则，参见: https://pay.weixin.qq.com/wiki/doc/api/tools/mch_pay.php?chapter=4_3
+签名算法由参数中 sign_type 字段决定，没有该字段或为空时使用 MD5
+仿真测试时使用仿真测试密钥签名，密钥在首次调用接口或 LoadSandboxSignKey 时获取，签名本身不发起网络请求
 */
 func (self *wechatPay) Sign(param interface{}) (string, error) {
+	return self.signWithType(param, paramSignType(param))
+}
+
+// 使用指定的签名算法签名，用于 sign_type 字段名不同的客户端支付参数
+func (self *wechatPay) signWithType(param interface{}, signType SignType) (string, error) {
+	if err := self.checkSandboxSign(signType); err != nil {
+		return "", err
+	}
 
 	if content, err := self.genContentStr(param); err != nil {
 		return "", err
 	} else {
-		return strings.ToUpper(md5Str(content)), nil
+		return self.signContent(content, signType)
 	}
 }
 
 func (self *wechatPay) VerifySign(param interface{}, sign string) error {
+	if err := self.checkSandboxSign(paramSignType(param)); err != nil {
+		return err
+	}
 
 	if content, err := self.genContentStr(param); err != nil {
 		return err
 	} else {
-		createdSign := strings.ToUpper(md5Str(content))
+		createdSign, err := self.signContent(content, paramSignType(param))
+		if err != nil {
+			return err
+		}
 		if createdSign != sign {
-			return errors.New("verify sign fail")
+			return &SignError{Sign: sign}
 		}
 	}
 
 	return nil
 }
 
+// 对 key-value 形式的报文验签，报文中出现的所有字段都参与签名，包括结构体中未声明的动态字段
+// 签名算法优先使用报文中的 sign_type 字段，没有该字段时使用 signType
+func (self *wechatPay) verifyMapSign(values map[string]string, signType SignType) error {
+	if declared := values["sign_type"]; declared != "" {
+		signType = SignType(declared)
+	}
+
+	if err := self.checkSandboxSign(signType); err != nil {
+		return err
+	}
+
+	sign := values["sign"]
+	createdSign, err := self.signContent(self.genMapContentStr(values), signType)
+	if err != nil {
+		return err
+	}
+	if createdSign != sign {
+		return &SignError{Sign: sign}
+	}
+
+	return nil
+}
+
+// 按签名算法签名，sign_type 为空时使用 MD5，不支持的签名算法返回错误
+func (self *wechatPay) signContent(content string, signType SignType) (string, error) {
+	switch signType {
+	case "", SIGN_TYPE_MD5:
+		return strings.ToUpper(md5Str(content)), nil
+	case SIGN_TYPE_HMAC_SHA256:
+		return strings.ToUpper(hmacSha256Str(content, self.signKey())), nil
+	default:
+		return "", errors.New(fmt.Sprintf("unsupported sign_type: %v", signType))
+	}
+}
+
+// 支持 sign_type 参数的接口所用的签名算法，默认为 MD5，单次请求可以通过 WithRequestSignType 覆盖
+// 签名算法只能是 SIGN_TYPE_MD5 或 SIGN_TYPE_HMAC_SHA256，传入其他值属于调用方的编程错误，创建 WechatPay 时直接 panic
+func WithSignType(signType SignType) Option {
+	if !isSupportedSignType(signType) {
+		panic(fmt.Sprintf("unsupported sign_type: %v", signType))
+	}
+
+	return func(pay *wechatPay) {
+		pay.signType = signType
+	}
+}
+
+// 单次请求的可选配置
+type RequestOption func(option *requestOption)
+
+type requestOption struct {
+	signType SignType
+}
+
+// 指定单次请求的签名算法，覆盖创建 WechatPay 时的签名算法，返回也按该算法验签
+// 不支持的签名算法在签名时返回错误，请求不会发送到微信
+func WithRequestSignType(signType SignType) RequestOption {
+	return func(option *requestOption) {
+		option.signType = signType
+	}
+}
+
+// 合并单次请求的配置，未指定的配置使用创建 WechatPay 时的配置
+func (self *wechatPay) requestOption(options []RequestOption) *requestOption {
+	option := &requestOption{signType: self.signType}
+	for _, o := range options {
+		o(option)
+	}
+
+	return option
+}
+
+func isSupportedSignType(signType SignType) bool {
+	return signType == SIGN_TYPE_MD5 || signType == SIGN_TYPE_HMAC_SHA256
+}
+
+// 读取参数中 sign_type 字段的值
+func paramSignType(param interface{}) SignType {
+	if param == nil {
+		return SIGN_TYPE_MD5
+	}
+
+	rv := reflect.Indirect(reflect.ValueOf(param))
+	if rv.Kind() != reflect.Struct {
+		return SIGN_TYPE_MD5
+	}
+
+	lt := rv.Type()
+	for i := 0; i < lt.NumField(); i++ {
+		if lt.Field(i).Tag.Get("xml") != "sign_type" {
+			continue
+		}
+
+		f := reflect.Indirect(rv.Field(i))
+		if f.IsValid() && f.String() != "" {
+			return SignType(f.String())
+		}
+	}
+
+	return SIGN_TYPE_MD5
+}
+
+func (self *wechatPay) genMapContentStr(values map[string]string) string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	contentStr := ""
+	for _, name := range names {
+		if name == "sign" || values[name] == "" {
+			continue
+		}
+
+		contentStr = contentStr + name + "=" + values[name] + "&"
+	}
+
+	return contentStr + "key=" + self.signKey()
+}
+
 func (self *wechatPay) genContentStr(param interface{}) (contentStr string, err error) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -93,7 +239,7 @@ func (self *wechatPay) genContentStr(param interface{}) (contentStr string, err
 		}
 	}
 
-	contentStr = contentStr + "key=" + self.apiSignKey
+	contentStr = contentStr + "key=" + self.signKey()
 
 	return contentStr, nil
 }
@@ -105,3 +251,9 @@ func md5Str(origin string) string {
 	cipherStr := h.Sum(nil)
 	return hex.EncodeToString(cipherStr) // 输出加密结果
 }
+
+func hmacSha256Str(origin string, key string) string {
+	h := hmac.New(sha256.New, []byte(key))
+	h.Write([]byte(origin))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -1,6 +1,11 @@
 package pay
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
 	"strings"
 	"testing"
 )
@@ -99,3 +104,131 @@ func Test_wechatPay_genContentStr(t *testing.T) {
 		t.Errorf("genContentStr fail for pointer. want: %v. get: %v", wantStr, result)
 	}
 }
+
+type testSignTypeParam struct {
+	S        string `xml:"s"`
+	SignType string `xml:"sign_type"`
+	Sign     string `xml:"sign"`
+}
+
+func Test_wechatPay_sign_hmacSha256(t *testing.T) {
+	p := &testSignTypeParam{
+		S:        "test",
+		SignType: string(SIGN_TYPE_HMAC_SHA256),
+	}
+
+	h := hmac.New(sha256.New, []byte(pay.apiSignKey))
+	h.Write([]byte("s=test&sign_type=HMAC-SHA256&key=test-Sign-key"))
+	want := strings.ToUpper(hex.EncodeToString(h.Sum(nil)))
+
+	result, err := pay.Sign(p)
+	if err != nil {
+		t.Errorf("Sign return err: %v", err)
+	}
+
+	if result != want {
+		t.Errorf("Sign fail for HMAC-SHA256. want: %v. get: %v", want, result)
+	}
+
+	if err := pay.VerifySign(p, result); err != nil {
+		t.Errorf("VerifySign fail for HMAC-SHA256: %v", err)
+	}
+
+	p.SignType = ""
+	if err := pay.VerifySign(p, result); err == nil {
+		t.Errorf("VerifySign should fail when sign_type mismatch")
+	}
+
+	values := map[string]string{"s": "test", "sign_type": "HMAC-SHA256", "sign": result}
+	if err := pay.verifyMapSign(values, SIGN_TYPE_MD5); err != nil {
+		t.Errorf("verifyMapSign should honour sign_type in values: %v", err)
+	}
+
+	delete(values, "sign_type")
+	values["sign"] = strings.ToUpper(hmacSha256Str("s=test&key=test-Sign-key", pay.apiSignKey))
+	if err := pay.verifyMapSign(values, SIGN_TYPE_HMAC_SHA256); err != nil {
+		t.Errorf("verifyMapSign should use default sign type: %v", err)
+	}
+}
+
+func Test_wechatPay_sign_unsupportedSignType(t *testing.T) {
+	p := &testSignTypeParam{S: "test", SignType: "SHA1"}
+	if _, err := pay.Sign(p); err == nil {
+		t.Errorf("Sign should fail for unsupported sign_type")
+	}
+
+	values := map[string]string{"s": "test", "sign_type": "SHA1", "sign": "X"}
+	if err := pay.verifyMapSign(values, SIGN_TYPE_MD5); err == nil {
+		t.Errorf("verifyMapSign should fail for unsupported sign_type")
+	}
+}
+
+func Test_wechatPay_ParseNotifyInfo_hmacSha256(t *testing.T) {
+	client := &wechatPay{apiSignKey: pay.apiSignKey}
+	WithSignType(SIGN_TYPE_HMAC_SHA256)(client)
+
+	values := map[string]string{"return_code": "SUCCESS", "result_code": "SUCCESS", "out_trade_no": "o0"}
+	values["sign"] = strings.ToUpper(hmacSha256Str(client.genMapContentStr(values), client.apiSignKey))
+
+	body := "<xml>"
+	for name, value := range values {
+		body += "<" + name + "><![CDATA[" + value + "]]></" + name + ">"
+	}
+	body += "</xml>"
+
+	if _, err := client.ParseNotifyInfo([]byte(body)); err != nil {
+		t.Errorf("ParseNotifyInfo should verify with configured sign type. err: %v", err)
+	}
+}
+
+func Test_WithSignType_unsupported(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("WithSignType should panic for unsupported sign_type")
+		}
+	}()
+
+	WithSignType("SHA1")
+}
+
+func Test_wechatPay_OrderQuery_requestSignType(t *testing.T) {
+	client := &wechatPay{
+		apiSignKey: pay.apiSignKey,
+		NonceLen:   16,
+		signType:   SIGN_TYPE_MD5,
+	}
+
+	client.nonSecureClient = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		data, _ := ioutil.ReadAll(r.Body)
+		request, _ := parseXMLMap(data)
+		if request["sign_type"] != string(SIGN_TYPE_HMAC_SHA256) {
+			t.Errorf("OrderQuery should send sign_type of request. get: %v", request["sign_type"])
+		}
+		if err := client.verifyMapSign(request, SIGN_TYPE_MD5); err != nil {
+			t.Errorf("OrderQuery should sign with sign_type of request. err: %v", err)
+		}
+
+		values := map[string]string{"return_code": "SUCCESS", "result_code": "SUCCESS", "trade_state": "SUCCESS"}
+		values["sign"] = strings.ToUpper(hmacSha256Str(client.genMapContentStr(values), client.apiSignKey))
+
+		body := "<xml>"
+		for name, value := range values {
+			body += "<" + name + "><![CDATA[" + value + "]]></" + name + ">"
+		}
+		body += "</xml>"
+
+		return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(body)), Header: http.Header{}}, nil
+	})}
+
+	resp, err := client.OrderQuery("", "o0", WithRequestSignType(SIGN_TYPE_HMAC_SHA256))
+	if err != nil {
+		t.Fatalf("OrderQuery should verify response with sign_type of request. err: %v", err)
+	}
+	if resp.TradeState != TRADE_STATE_SUCCESS {
+		t.Errorf("OrderQuery fail for trade_state. get: %v", resp.TradeState)
+	}
+
+	if _, err := client.OrderQuery("", "o0", WithRequestSignType("SHA1")); err == nil {
+		t.Errorf("OrderQuery should fail for unsupported sign_type of request")
+	}
+}
@@ -1,10 +1,8 @@
 package pay
 
 import (
-	"bytes"
+	"context"
 	"encoding/xml"
-	"io/ioutil"
-	"net/http"
 
 	"github.com/kataras/iris/core/errors"
 )
@@ -49,6 +47,10 @@ type transferParam struct {
 
 // 企业付款到用户零钱账户
 func (self *wechatPay) Transfer(openId string, partnerTradeNo string, amount int64, checkName CheckNameMode, receiverName string, desc string, deviceInfo string, ip string) (*TransferResponse, error) {
+	return self.TransferWithContext(context.Background(), openId, partnerTradeNo, amount, checkName, receiverName, desc, deviceInfo, ip)
+}
+
+func (self *wechatPay) TransferWithContext(ctx context.Context, openId string, partnerTradeNo string, amount int64, checkName CheckNameMode, receiverName string, desc string, deviceInfo string, ip string) (*TransferResponse, error) {
 
 	if self.secureClient == nil {
 		return nil, errors.New("need create secure wechat with CA")
@@ -68,27 +70,18 @@ func (self *wechatPay) Transfer(openId string, partnerTradeNo string, amount int
 		SPBillCreateIP: ip,
 	}
 
-	sign, err := self.Sign(param)
-	if err != nil {
+	if err := validateTransfer(param); err != nil {
 		return nil, err
 	}
 
-	param.Sign = sign
-
-	body, err := xml.Marshal(param)
+	sign, err := self.signRequest(ctx, param)
 	if err != nil {
 		return nil, err
 	}
 
-	request, err := http.NewRequest("POST", TRANSFER_URL, bytes.NewReader(body))
-	if err != nil {
-		return nil, err
-	}
-	result, err := self.secureClient.Do(request)
-	if err != nil {
-		return nil, err
-	}
-	data, err := ioutil.ReadAll(result.Body)
+	param.Sign = sign
+
+	data, _, err := self.post(ctx, self.secureClient, TRANSFER_URL, param)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,85 @@
+package pay
+
+import (
+	"context"
+	"encoding/xml"
+)
+
+/*
+查询企业付款到零钱
+https://pay.weixin.qq.com/wiki/doc/api/tools/mch_pay.php?chapter=14_3
+*/
+
+const (
+	GET_TRANSFER_INFO_URL = "https://api.mch.weixin.qq.com/mmpaymkttransfers/gettransferinfo"
+)
+
+type TransferStatus string
+
+const (
+	TRANSFER_STATUS_SUCCESS    TransferStatus = "SUCCESS"    // 转账成功
+	TRANSFER_STATUS_FAILED     TransferStatus = "FAILED"     // 转账失败
+	TRANSFER_STATUS_PROCESSING TransferStatus = "PROCESSING" // 处理中
+)
+
+type transferInfoParam struct {
+	AppId          string `xml:"appid"`
+	Mchid          string `xml:"mch_id"`
+	NonceStr       string `xml:"nonce_str"`
+	Sign           string `xml:"sign"`
+	PartnerTradeNo string `xml:"partner_trade_no"`
+}
+
+type TransferInfoResponse struct {
+	ReturnCode string `xml:"return_code"`
+	ReturnMsg  string `xml:"return_msg"`
+	ResultCode string `xml:"result_code"`
+	ErrCode    string `xml:"err_code"`
+	ErrCodeDes string `xml:"err_code_des"`
+
+	PartnerTradeNo string         `xml:"partner_trade_no"` // 商户单号
+	AppId          string         `xml:"appid"`
+	MchId          string         `xml:"mch_id"`
+	DetailId       string         `xml:"detail_id"` // 付款单号
+	Status         TransferStatus `xml:"status"`
+	Reason         string         `xml:"reason"` // 失败原因
+	Openid         string         `xml:"openid"`
+	TransferName   string         `xml:"transfer_name"`  // 收款用户姓名
+	PaymentAmount  int64          `xml:"payment_amount"` // 付款金额，单位为分
+	TransferTime   string         `xml:"transfer_time"`  // 发起转账的时间
+	PaymentTime    string         `xml:"payment_time"`   // 企业付款成功时间
+	Desc           string         `xml:"desc"`           // 企业付款备注
+}
+
+// 查询企业付款到零钱的结果，用于 Transfer 超时等无法确定结果的情况
+func (self *wechatPay) GetTransferInfo(partnerTradeNo string) (*TransferInfoResponse, error) {
+	return self.GetTransferInfoWithContext(context.Background(), partnerTradeNo)
+}
+
+func (self *wechatPay) GetTransferInfoWithContext(ctx context.Context, partnerTradeNo string) (*TransferInfoResponse, error) {
+	param := &transferInfoParam{
+		AppId:          self.AppId,
+		Mchid:          self.mchId,
+		NonceStr:       randString(self.NonceLen),
+		PartnerTradeNo: partnerTradeNo,
+	}
+
+	sign, err := self.signRequest(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	param.Sign = sign
+
+	data, _, err := self.post(ctx, self.secureClient, GET_TRANSFER_INFO_URL, param)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &TransferInfoResponse{}
+	if err = xml.Unmarshal(data, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
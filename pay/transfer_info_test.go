@@ -0,0 +1,65 @@
+package pay
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func Test_wechatPay_GetTransferInfo(t *testing.T) {
+	body := []byte(`<xml>
+<return_code><![CDATA[SUCCESS]]></return_code>
+<return_msg><![CDATA[获取成功]]></return_msg>
+<result_code><![CDATA[SUCCESS]]></result_code>
+<mch_id>10000098</mch_id>
+<appid><![CDATA[wxe062425f740c30d8]]></appid>
+<detail_id><![CDATA[1000000000201503283103439304]]></detail_id>
+<partner_trade_no><![CDATA[1000005901201407261446939628]]></partner_trade_no>
+<status><![CDATA[SUCCESS]]></status>
+<payment_amount>650</payment_amount>
+<openid><![CDATA[oxTWIuGaIt6gTKsQRLau2M0yL16E]]></openid>
+<transfer_time><![CDATA[2015-04-21 20:00:00]]></transfer_time>
+<transfer_name><![CDATA[测试]]></transfer_name>
+<desc><![CDATA[福利测试]]></desc>
+</xml>`)
+
+	client := &wechatPay{
+		apiSignKey: pay.apiSignKey,
+		NonceLen:   16,
+		secureClient: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			if r.URL.String() != GET_TRANSFER_INFO_URL {
+				t.Errorf("request wrong url: %v", r.URL)
+			}
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewReader(body)), Header: http.Header{}}, nil
+		})},
+	}
+
+	resp, err := client.GetTransferInfo("1000005901201407261446939628")
+	if err != nil {
+		t.Fatalf("GetTransferInfo return err: %v", err)
+	}
+
+	if resp.Status != TRANSFER_STATUS_SUCCESS || resp.PaymentAmount != 650 {
+		t.Errorf("GetTransferInfo fail for status and amount. get: %+v", resp)
+	}
+
+	if resp.DetailId != "1000000000201503283103439304" || resp.TransferName != "测试" || resp.TransferTime != "2015-04-21 20:00:00" {
+		t.Errorf("GetTransferInfo fail for fields. get: %+v", resp)
+	}
+
+	body = []byte(`<xml>
+<return_code><![CDATA[SUCCESS]]></return_code>
+<result_code><![CDATA[FAIL]]></result_code>
+<err_code><![CDATA[NOT_FOUND]]></err_code>
+<err_code_des><![CDATA[指定单号数据不存在]]></err_code_des>
+</xml>`)
+
+	resp, err = client.GetTransferInfo("p0")
+	if resp != nil {
+		t.Errorf("GetTransferInfo should not return response when fail. get: %+v", resp)
+	}
+	if e, ok := err.(*ResultError); !ok || e.ErrCode != "NOT_FOUND" || !e.IsBusinessError() {
+		t.Errorf("GetTransferInfo should return *ResultError when result_code FAIL. get: %v", err)
+	}
+}
@@ -1,10 +1,9 @@
 package pay
 
 import (
-	"bytes"
+	"context"
+	"encoding/json"
 	"encoding/xml"
-	"io/ioutil"
-	"net/http"
 	"time"
 )
 
@@ -21,26 +20,88 @@ const (
 	TRADE_TYPE_JSAPI  TradeType = "JSAPI"
 	TRADE_TYPE_NATIVE TradeType = "NATIVE"
 	TRADE_TYPE_APP    TradeType = "APP"
+	TRADE_TYPE_MWEB   TradeType = "MWEB" // H5 支付
 )
 
+// 指定支付方式
+type LimitPay string
+
+const (
+	LIMIT_PAY_NO_CREDIT LimitPay = "no_credit" // 不能使用信用卡支付
+)
+
+// 统一下单的参数，金额单位为分，为空的字段不传
+type UnifiedOrderRequest struct {
+	DeviceInfo     string       // 终端设备号，PC网页或公众号内支付可以传 WEB
+	Body           string       // 商品描述
+	Detail         *GoodsDetail // 商品详情，用于单品优惠
+	Attach         string       // 附加数据，在查询订单和支付通知中原样返回
+	OutTradeNo     string       // 商户订单号
+	FeeType        string       // 货币类型，默认人民币 CNY
+	TotalFee       int64        // 订单总金额
+	SPBillCreateIP string       // 用户端 ip，H5 支付时必须为用户的真实 ip
+	TimeStart      time.Time    // 交易起始时间，为零值时不传
+	TimeExpire     time.Time    // 交易结束时间，为零值时不传，与起始时间至少间隔 5 分钟
+	GoodsTag       string       // 订单优惠标记
+	NotifyUrl      string       // 支付结果通知地址
+	TradeType      TradeType    // 交易类型
+	ProductId      string       // 商品 id，NATIVE 时必传
+	LimitPay       LimitPay     // 指定支付方式
+	Openid         string       // 用户标识，JSAPI 时必传
+	Receipt        bool         // 是否在支付成功消息和支付详情页中出现开票入口
+	ProfitSharing  bool         // 是否需要分账
+	SceneInfo      *SceneInfo   // 场景信息，H5 支付时必传
+	SignType       SignType     // 本次下单的签名算法，为空时使用创建 WechatPay 时的签名算法
+}
+
+// 单品优惠的商品详情，编码为 json 后放在 detail 字段中
+type GoodsDetail struct {
+	CostPrice   int64   `json:"cost_price,omitempty"` // 订单原价，商户侧一张小票订单可能被分多次支付时使用
+	ReceiptId   string  `json:"receipt_id,omitempty"` // 商家小票 id
+	GoodsDetail []Goods `json:"goods_detail"`
+}
+
+// 单品信息，金额单位为分
+type Goods struct {
+	GoodsId      string `json:"goods_id"`                 // 商户侧商品编码
+	WxpayGoodsId string `json:"wxpay_goods_id,omitempty"` // 微信侧商品编码
+	GoodsName    string `json:"goods_name,omitempty"`
+	Quantity     int    `json:"quantity"`
+	Price        int64  `json:"price"` // 商品单价，有优惠时为优惠后的单价
+}
+
+// 编码为 detail 字段要求的 json
+func (detail *GoodsDetail) Encode() string {
+	// 只包含字符串与数字字段，编码不会失败
+	data, _ := json.Marshal(detail)
+	return string(data)
+}
+
 type UnifiedOrderParam struct {
 	AppId          string `xml:"appid"`
 	Mchid          string `xml:"mch_id"`
 	DeviceInfo     string `xml:"device_info"`
 	NonceStr       string `xml:"nonce_str"`
 	Sign           string `xml:"sign"`
+	SignType       string `xml:"sign_type"`
 	Body           string `xml:"body"`
 	Detail         string `xml:"detail"`
 	Attach         string `xml:"attach"`
 	OutTradeNo     string `xml:"out_trade_no"`
+	FeeType        string `xml:"fee_type"`
 	TotalFee       int64  `xml:"total_fee"`
 	SPBillCreateIP string `xml:"spbill_create_ip"`
 	TimeStart      string `xml:"time_start"`
 	TimeExpire     string `xml:"time_expire"`
 	NotifyUrl      string `xml:"notify_url"`
 	TradeType      string `xml:"trade_type"`
+	LimitPay       string `xml:"limit_pay"`
 	Openid         string `xml:"openid"`
+	Receipt        string `xml:"receipt"`
 	GoodsTag       string `xml:"goods_tag"`
+	ProductId      string `xml:"product_id"`     // trade_type 为 NATIVE 时必传
+	ProfitSharing  string `xml:"profit_sharing"` // Y-需要分账，N-不分账，默认不分账
+	SceneInfo      string `xml:"scene_info"`     // SceneInfo 的 json
 }
 
 type UnifiedOrderResponse struct {
@@ -58,47 +119,100 @@ type UnifiedOrderResponse struct {
 	TradeType string `xml:"trade_type"`
 	PrePayId  string `xml:"prepay_id"`
 	CodeUrl   string `xml:"code_url"`
+	MwebUrl   string `xml:"mweb_url"` // H5 支付的跳转链接
 }
 
-// 统一下单接口
+// 统一下单接口，按位置传入常用参数，是 UnifiedOrderByRequest 的简单封装
+// 分账、商品 id、场景信息、用户 ip 等其他参数使用 UnifiedOrderByRequest 并设置 UnifiedOrderRequest 的对应字段
 func (self *wechatPay) UnifiedOrder(openId, body, attach, goodsTag, outTradeNo string, totalFee int64, timeStart, timeExpire time.Time, notifyUrl string, tradeType TradeType) (*UnifiedOrderResponse, error) {
-	param := &UnifiedOrderParam{
-		AppId:      self.AppId,
-		Mchid:      self.mchId,
-		NonceStr:   randString(self.NonceLen),
+	return self.UnifiedOrderWithContext(context.Background(), openId, body, attach, goodsTag, outTradeNo, totalFee, timeStart, timeExpire, notifyUrl, tradeType)
+}
+
+func (self *wechatPay) UnifiedOrderWithContext(ctx context.Context, openId, body, attach, goodsTag, outTradeNo string, totalFee int64, timeStart, timeExpire time.Time, notifyUrl string, tradeType TradeType) (*UnifiedOrderResponse, error) {
+	request := &UnifiedOrderRequest{
 		Body:       body,
 		Attach:     attach,
 		OutTradeNo: outTradeNo,
 		TotalFee:   totalFee,
-		TimeStart:  timeStart.Format("20060102150405"),
-		TimeExpire: timeExpire.Format("20060102150405"),
+		TimeStart:  timeStart,
+		TimeExpire: timeExpire,
 		GoodsTag:   goodsTag,
 		NotifyUrl:  notifyUrl,
-		TradeType:  string(tradeType),
+		TradeType:  tradeType,
 		Openid:     openId,
 	}
 
-	sign, err := self.Sign(param)
-	if err != nil {
-		return nil, err
-	}
+	return self.UnifiedOrderByRequestWithContext(ctx, request)
+}
 
-	param.Sign = sign
+// 使用 UnifiedOrderRequest 统一下单，支持全部参数
+func (self *wechatPay) UnifiedOrderByRequest(request *UnifiedOrderRequest) (*UnifiedOrderResponse, error) {
+	return self.UnifiedOrderByRequestWithContext(context.Background(), request)
+}
 
-	requestBody, err := xml.Marshal(param)
-	if err != nil {
-		return nil, err
+func (self *wechatPay) UnifiedOrderByRequestWithContext(ctx context.Context, request *UnifiedOrderRequest) (*UnifiedOrderResponse, error) {
+	return self.unifiedOrder(ctx, self.newUnifiedOrderParam(request))
+}
+
+func (self *wechatPay) newUnifiedOrderParam(request *UnifiedOrderRequest) *UnifiedOrderParam {
+	param := &UnifiedOrderParam{
+		AppId:          self.AppId,
+		Mchid:          self.mchId,
+		DeviceInfo:     request.DeviceInfo,
+		NonceStr:       randString(self.NonceLen),
+		SignType:       string(self.signType),
+		Body:           request.Body,
+		Attach:         request.Attach,
+		OutTradeNo:     request.OutTradeNo,
+		FeeType:        request.FeeType,
+		TotalFee:       request.TotalFee,
+		SPBillCreateIP: request.SPBillCreateIP,
+		GoodsTag:       request.GoodsTag,
+		NotifyUrl:      request.NotifyUrl,
+		TradeType:      string(request.TradeType),
+		ProductId:      request.ProductId,
+		LimitPay:       string(request.LimitPay),
+		Openid:         request.Openid,
 	}
 
-	request, err := http.NewRequest("POST", UNIFIED_ORDER_URL, bytes.NewReader(requestBody))
-	if err != nil {
+	if request.SignType != "" {
+		param.SignType = string(request.SignType)
+	}
+	if request.Detail != nil {
+		param.Detail = request.Detail.Encode()
+	}
+	if !request.TimeStart.IsZero() {
+		param.TimeStart = request.TimeStart.Format("20060102150405")
+	}
+	if !request.TimeExpire.IsZero() {
+		param.TimeExpire = request.TimeExpire.Format("20060102150405")
+	}
+	if request.Receipt {
+		param.Receipt = "Y"
+	}
+	if request.ProfitSharing {
+		param.ProfitSharing = "Y"
+	}
+	if request.SceneInfo != nil {
+		param.SceneInfo = request.SceneInfo.Encode()
+	}
+
+	return param
+}
+
+func (self *wechatPay) unifiedOrder(ctx context.Context, param *UnifiedOrderParam) (*UnifiedOrderResponse, error) {
+	if err := validateUnifiedOrder(param); err != nil {
 		return nil, err
 	}
-	result, err := self.nonSecureClient.Do(request)
+
+	sign, err := self.signRequest(ctx, param)
 	if err != nil {
 		return nil, err
 	}
-	data, err := ioutil.ReadAll(result.Body)
+
+	param.Sign = sign
+
+	data, _, err := self.post(ctx, self.nonSecureClient, UNIFIED_ORDER_URL, param)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,56 @@
+package pay
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_wechatPay_newUnifiedOrderParam(t *testing.T) {
+	client := &wechatPay{AppId: "wx0", mchId: "10000100", NonceLen: 16, signType: SIGN_TYPE_MD5}
+
+	param := client.newUnifiedOrderParam(&UnifiedOrderRequest{
+		DeviceInfo: "WEB",
+		Body:       "腾讯充值中心-QQ会员充值",
+		Detail: &GoodsDetail{
+			CostPrice: 608800,
+			ReceiptId: "wx123",
+			GoodsDetail: []Goods{
+				{GoodsId: "商品编码", WxpayGoodsId: "1001", GoodsName: "iPhone6s 16G", Quantity: 1, Price: 528800},
+			},
+		},
+		OutTradeNo:     "o0",
+		TotalFee:       528800,
+		SPBillCreateIP: "123.12.12.123",
+		TimeStart:      time.Date(2017, 12, 1, 10, 0, 0, 0, time.Local),
+		TradeType:      TRADE_TYPE_NATIVE,
+		ProductId:      "p0",
+		LimitPay:       LIMIT_PAY_NO_CREDIT,
+		Receipt:        true,
+	})
+
+	wantDetail := `{"cost_price":608800,"receipt_id":"wx123","goods_detail":[{"goods_id":"商品编码","wxpay_goods_id":"1001","goods_name":"iPhone6s 16G","quantity":1,"price":528800}]}`
+	if param.Detail != wantDetail {
+		t.Errorf("newUnifiedOrderParam fail for detail. want: %v. get: %v", wantDetail, param.Detail)
+	}
+
+	if param.TimeStart != "20171201100000" || param.TimeExpire != "" {
+		t.Errorf("newUnifiedOrderParam fail for time. get: %v, %v", param.TimeStart, param.TimeExpire)
+	}
+
+	if param.AppId != "wx0" || param.Mchid != "10000100" || param.DeviceInfo != "WEB" || param.SPBillCreateIP != "123.12.12.123" || param.ProductId != "p0" {
+		t.Errorf("newUnifiedOrderParam fail for fields. get: %+v", param)
+	}
+
+	if param.LimitPay != "no_credit" || param.Receipt != "Y" || param.ProfitSharing != "" || param.SceneInfo != "" {
+		t.Errorf("newUnifiedOrderParam fail for optional fields. get: %+v", param)
+	}
+
+	if param.SignType != string(SIGN_TYPE_MD5) {
+		t.Errorf("newUnifiedOrderParam should use default sign_type. get: %v", param.SignType)
+	}
+
+	param = client.newUnifiedOrderParam(&UnifiedOrderRequest{SignType: SIGN_TYPE_HMAC_SHA256})
+	if param.SignType != string(SIGN_TYPE_HMAC_SHA256) {
+		t.Errorf("newUnifiedOrderParam should use sign_type of request. get: %v", param.SignType)
+	}
+}
@@ -0,0 +1,117 @@
+package pay
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+/*
+发送请求前对参数做本地校验，避免可以提前发现的 PARAM_ERROR
+校验失败时返回 *ValidationError
+*/
+
+const (
+	MAX_BODY_LEN          = 128 // 商品描述最大字节数
+	MAX_OUT_TRADE_NO_LEN  = 32  // 商户订单号、商户付款单号最大长度
+	MAX_OUT_REFUND_NO_LEN = 64  // 商户退款单号最大长度
+	MIN_ORDER_DURATION    = 5 * time.Minute
+	MIN_TRANSFER_AMOUNT   = 30 // 企业付款最小金额，单位为分
+)
+
+// 商户订单号只能是数字、大小写字母及 _-|*@
+var tradeNoPattern = regexp.MustCompile(`^[0-9A-Za-z_\-|*@]+$`)
+
+func validateUnifiedOrder(param *UnifiedOrderParam) error {
+	if param.Body == "" {
+		return &ValidationError{Field: "body", Reason: "is required"}
+	}
+	if len(param.Body) > MAX_BODY_LEN {
+		return &ValidationError{Field: "body", Reason: fmt.Sprintf("longer than %v bytes", MAX_BODY_LEN)}
+	}
+
+	if err := validateTradeNo("out_trade_no", param.OutTradeNo, MAX_OUT_TRADE_NO_LEN); err != nil {
+		return err
+	}
+
+	if param.TotalFee <= 0 {
+		return &ValidationError{Field: "total_fee", Reason: "must be greater than 0"}
+	}
+
+	if param.TimeStart != "" && param.TimeExpire != "" {
+		start, err := time.Parse("20060102150405", param.TimeStart)
+		if err != nil {
+			return &ValidationError{Field: "time_start", Reason: "must be yyyyMMddHHmmss"}
+		}
+		expire, err := time.Parse("20060102150405", param.TimeExpire)
+		if err != nil {
+			return &ValidationError{Field: "time_expire", Reason: "must be yyyyMMddHHmmss"}
+		}
+		if expire.Sub(start) < MIN_ORDER_DURATION {
+			return &ValidationError{Field: "time_expire", Reason: fmt.Sprintf("must be at least %v after time_start", MIN_ORDER_DURATION)}
+		}
+	}
+
+	return nil
+}
+
+func validateRefund(param *RefundParam) error {
+	if param.TransactionId == "" && param.OutTradeNo == "" {
+		return &ValidationError{Field: "out_trade_no", Reason: "transaction_id or out_trade_no is required"}
+	}
+	if param.OutTradeNo != "" {
+		if err := validateTradeNo("out_trade_no", param.OutTradeNo, MAX_OUT_TRADE_NO_LEN); err != nil {
+			return err
+		}
+	}
+
+	if err := validateTradeNo("out_refund_no", param.OutRefundNo, MAX_OUT_REFUND_NO_LEN); err != nil {
+		return err
+	}
+
+	if param.TotalFee <= 0 {
+		return &ValidationError{Field: "total_fee", Reason: "must be greater than 0"}
+	}
+	if param.RefundFee <= 0 {
+		return &ValidationError{Field: "refund_fee", Reason: "must be greater than 0"}
+	}
+	if param.RefundFee > param.TotalFee {
+		return &ValidationError{Field: "refund_fee", Reason: "greater than total_fee"}
+	}
+
+	return nil
+}
+
+func validateTransfer(param *transferParam) error {
+	if err := validateTradeNo("partner_trade_no", param.PartnerTradeNo, MAX_OUT_TRADE_NO_LEN); err != nil {
+		return err
+	}
+
+	if param.Amount < MIN_TRANSFER_AMOUNT {
+		return &ValidationError{Field: "amount", Reason: fmt.Sprintf("less than %v", MIN_TRANSFER_AMOUNT)}
+	}
+
+	if param.CheckName == FORCE_CHECK && param.ReUserName == "" {
+		return &ValidationError{Field: "re_user_name", Reason: "is required when check_name is FORCE_CHECK"}
+	}
+
+	if param.Desc == "" {
+		return &ValidationError{Field: "desc", Reason: "is required"}
+	}
+
+	return nil
+}
+
+func validateTradeNo(field, tradeNo string, maxLen int) error {
+	if tradeNo == "" {
+		return &ValidationError{Field: field, Reason: "is required"}
+	}
+	if len(tradeNo) > maxLen {
+		return &ValidationError{Field: field, Reason: fmt.Sprintf("longer than %v characters", maxLen)}
+	}
+	if !tradeNoPattern.MatchString(tradeNo) {
+		return &ValidationError{Field: field, Reason: "only digits, letters and _-|*@ are allowed"}
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package pay
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_validateUnifiedOrder(t *testing.T) {
+	valid := func() *UnifiedOrderParam {
+		return &UnifiedOrderParam{
+			Body:       "腾讯充值中心-QQ会员充值",
+			OutTradeNo: "20150806125346",
+			TotalFee:   88,
+			TimeStart:  "20171201100000",
+			TimeExpire: "20171201100500",
+		}
+	}
+
+	if err := validateUnifiedOrder(valid()); err != nil {
+		t.Errorf("validateUnifiedOrder should pass. err: %v", err)
+	}
+
+	cases := map[string]func(param *UnifiedOrderParam){
+		"body":         func(param *UnifiedOrderParam) { param.Body = strings.Repeat("商品", 22) },
+		"out_trade_no": func(param *UnifiedOrderParam) { param.OutTradeNo = "order#1" },
+		"total_fee":    func(param *UnifiedOrderParam) { param.TotalFee = 0 },
+		"time_expire":  func(param *UnifiedOrderParam) { param.TimeExpire = "20171201100459" },
+	}
+	for field, modify := range cases {
+		param := valid()
+		modify(param)
+
+		if e, ok := validateUnifiedOrder(param).(*ValidationError); !ok || e.Field != field {
+			t.Errorf("validateUnifiedOrder should fail for %v. get: %v", field, e)
+		}
+	}
+}
+
+func Test_validateRefund(t *testing.T) {
+	param := &RefundParam{OutTradeNo: "o0", OutRefundNo: "r0", TotalFee: 100, RefundFee: 100}
+	if err := validateRefund(param); err != nil {
+		t.Errorf("validateRefund should pass. err: %v", err)
+	}
+
+	param.RefundFee = 101
+	if e, ok := validateRefund(param).(*ValidationError); !ok || e.Field != "refund_fee" {
+		t.Errorf("validateRefund should fail for refund_fee. get: %v", e)
+	}
+
+	param = &RefundParam{TransactionId: "t0", OutRefundNo: "r0", TotalFee: 100, RefundFee: 100}
+	if err := validateRefund(param); err != nil {
+		t.Errorf("validateRefund should pass without out_trade_no. err: %v", err)
+	}
+
+	for _, outTradeNo := range []string{strings.Repeat("o", 33), "order#1"} {
+		param.OutTradeNo = outTradeNo
+		if e, ok := validateRefund(param).(*ValidationError); !ok || e.Field != "out_trade_no" {
+			t.Errorf("validateRefund should fail for out_trade_no %v. get: %v", outTradeNo, e)
+		}
+	}
+}
+
+func Test_validateTransfer(t *testing.T) {
+	param := &transferParam{PartnerTradeNo: "p0", Amount: 30, CheckName: string(NO_CHECK), Desc: "理赔"}
+	if err := validateTransfer(param); err != nil {
+		t.Errorf("validateTransfer should pass. err: %v", err)
+	}
+
+	param.Amount = 29
+	if e, ok := validateTransfer(param).(*ValidationError); !ok || e.Field != "amount" {
+		t.Errorf("validateTransfer should fail for amount. get: %v", e)
+	}
+
+	param.Amount = 100
+	param.CheckName = FORCE_CHECK
+	if e, ok := validateTransfer(param).(*ValidationError); !ok || e.Field != "re_user_name" {
+		t.Errorf("validateTransfer should fail for re_user_name. get: %v", e)
+	}
+}
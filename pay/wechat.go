@@ -1,9 +1,12 @@
 package pay
 
 import (
+	"context"
+	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
 	"net/http"
+	"sync"
 	"time"
 )
 
@@ -12,20 +15,120 @@ type WechatPay interface {
 	GetNonceStr() string
 	Sign(param interface{}) (string, error)          // 生成签名
 	VerifySign(param interface{}, sign string) error // 验签
+	LoadSandboxSignKey(ctx context.Context) error    // 仿真测试时获取仿真测试密钥，需要在启动时调用
 
 	// ============功能方法============
+	// 微信返回 return_code 或 result_code 失败时，返回 *ResultError；返回验签失败时，返回 *SignError
+	// 统一下单、退款、企业付款的参数未通过本地校验时，返回 *ValidationError，请求不会发送到微信
+	// XxxWithContext 方法在 ctx 取消或超时时中断请求，不带 ctx 的方法只受 client 超时时间限制
+	// 支持 sign_type 的接口可以通过 WithRequestSignType、UnifiedOrderRequest.SignType 或 MicropayOptions.SignType 指定单次请求的签名算法
 	// 向用户账户转账接口
 	Transfer(openId string, partnerTradeNo string, amount int64, checkName CheckNameMode, receiverName string, desc string, deviceInfo string, ip string) (*TransferResponse, error)
+	TransferWithContext(ctx context.Context, openId string, partnerTradeNo string, amount int64, checkName CheckNameMode, receiverName string, desc string, deviceInfo string, ip string) (*TransferResponse, error)
+	// 查询向用户账户转账的结果
+	GetTransferInfo(partnerTradeNo string) (*TransferInfoResponse, error)
+	GetTransferInfoWithContext(ctx context.Context, partnerTradeNo string) (*TransferInfoResponse, error)
+	// 企业付款到银行卡
+	PayBank(partnerTradeNo, bankNo, trueName string, bankCode BankCode, amount int64, desc string) (*PayBankResponse, error)
+	PayBankWithContext(ctx context.Context, partnerTradeNo, bankNo, trueName string, bankCode BankCode, amount int64, desc string) (*PayBankResponse, error)
+	// 查询企业付款到银行卡的结果
+	QueryBank(partnerTradeNo string) (*QueryBankResponse, error)
+	QueryBankWithContext(ctx context.Context, partnerTradeNo string) (*QueryBankResponse, error)
+
+	// 现金红包 - 发放普通红包
+	SendRedPack(request *RedPackRequest) (*SendRedPackResponse, error)
+	SendRedPackWithContext(ctx context.Context, request *RedPackRequest) (*SendRedPackResponse, error)
+	// 现金红包 - 发放裂变红包
+	SendGroupRedPack(request *RedPackRequest) (*SendRedPackResponse, error)
+	SendGroupRedPackWithContext(ctx context.Context, request *RedPackRequest) (*SendRedPackResponse, error)
+	// 现金红包 - 查询红包记录
+	GetRedPackInfo(mchBillNo string) (*RedPackInfoResponse, error)
+	GetRedPackInfoWithContext(ctx context.Context, mchBillNo string) (*RedPackInfoResponse, error)
+
+	// 代金券 - 发放代金券
+	SendCoupon(couponStockId, partnerTradeNo, openId string) (*SendCouponResponse, error)
+	SendCouponWithContext(ctx context.Context, couponStockId, partnerTradeNo, openId string) (*SendCouponResponse, error)
+	// 代金券 - 查询代金券批次
+	QueryCouponStock(couponStockId string) (*CouponStockResponse, error)
+	QueryCouponStockWithContext(ctx context.Context, couponStockId string) (*CouponStockResponse, error)
+	// 代金券 - 查询代金券信息
+	QueryCouponsInfo(couponId, openId, stockId string) (*CouponInfoResponse, error)
+	QueryCouponsInfoWithContext(ctx context.Context, couponId, openId, stockId string) (*CouponInfoResponse, error)
 
 	// 微信支付 - 统一下单接口
 	UnifiedOrder(openId, body, attach, goodsTag, outTradeNo string, totalFee int64, timeStart, timeExpire time.Time, notifyUrl string, tradeType TradeType) (*UnifiedOrderResponse, error)
+	UnifiedOrderWithContext(ctx context.Context, openId, body, attach, goodsTag, outTradeNo string, totalFee int64, timeStart, timeExpire time.Time, notifyUrl string, tradeType TradeType) (*UnifiedOrderResponse, error)
+	// 微信支付 - 使用 UnifiedOrderRequest 统一下单，支持全部参数
+	UnifiedOrderByRequest(request *UnifiedOrderRequest) (*UnifiedOrderResponse, error)
+	UnifiedOrderByRequestWithContext(ctx context.Context, request *UnifiedOrderRequest) (*UnifiedOrderResponse, error)
+	// 微信支付 - 生成公众号、小程序调起支付的参数
+	JSAPIPayParams(resp *UnifiedOrderResponse, options ...RequestOption) (*JSAPIPayParams, error)
+	// 微信支付 - 生成 APP 调起支付的参数
+	AppPayParams(resp *UnifiedOrderResponse) (*AppPayParams, error)
+	// 微信支付 - 生成扫码支付模式一的二维码链接
+	NativeBizPayUrl(productId string) (string, error)
+	// 微信支付 - 查询订单接口
+	OrderQuery(transactionId, outTradeNo string, options ...RequestOption) (*OrderQueryResponse, error)
+	OrderQueryWithContext(ctx context.Context, transactionId, outTradeNo string, options ...RequestOption) (*OrderQueryResponse, error)
+	// 微信支付 - 付款码支付，等待用户支付并在超时后撤销订单
+	Micropay(authCode, body, attach, outTradeNo string, totalFee int64, ip string, options *MicropayOptions) (*MicropayResult, error)
+	MicropayWithContext(ctx context.Context, authCode, body, attach, outTradeNo string, totalFee int64, ip string, options *MicropayOptions) (*MicropayResult, error)
+	// 微信支付 - 撤销订单接口，用于付款码支付
+	Reverse(transactionId, outTradeNo string, options ...RequestOption) (*ReverseResult, error)
+	ReverseWithContext(ctx context.Context, transactionId, outTradeNo string, options ...RequestOption) (*ReverseResult, error)
+	// 微信支付 - 关闭订单接口
+	CloseOrder(outTradeNo string, options ...RequestOption) (*CloseOrderResponse, error)
+	CloseOrderWithContext(ctx context.Context, outTradeNo string, options ...RequestOption) (*CloseOrderResponse, error)
 	// 微信支付 - 退款接口
-	Refund(transactionId, outTradeNo, outRefundNo string, orderTotalFee, refundFee int64, notifyUrl, refundDesc string) (*RefundResponse, error)
+	Refund(transactionId, outTradeNo, outRefundNo string, orderTotalFee, refundFee int64, notifyUrl, refundDesc string, options ...RequestOption) (*RefundResponse, error)
+	RefundWithContext(ctx context.Context, transactionId, outTradeNo, outRefundNo string, orderTotalFee, refundFee int64, notifyUrl, refundDesc string, options ...RequestOption) (*RefundResponse, error)
+	// 微信支付 - 查询退款接口
+	RefundQuery(transactionId, outTradeNo, outRefundNo, refundId string, offset int, options ...RequestOption) (*RefundQueryResponse, error)
+	RefundQueryWithContext(ctx context.Context, transactionId, outTradeNo, outRefundNo, refundId string, offset int, options ...RequestOption) (*RefundQueryResponse, error)
+	// 微信支付 - 下载交易账单
+	DownloadBill(billDate time.Time, billType BillType, gzipped bool) (*BillReader, error)
+	DownloadBillWithContext(ctx context.Context, billDate time.Time, billType BillType, gzipped bool) (*BillReader, error)
+	// 微信支付 - 下载资金账单
+	DownloadFundFlow(billDate time.Time, accountType AccountType, gzipped bool) (*FundFlowReader, error)
+	DownloadFundFlowWithContext(ctx context.Context, billDate time.Time, accountType AccountType, gzipped bool) (*FundFlowReader, error)
+
+	// 分账 - 添加分账接收方
+	ProfitSharingAddReceiver(receiver *ProfitSharingReceiver) (*ProfitSharingReceiverResponse, error)
+	ProfitSharingAddReceiverWithContext(ctx context.Context, receiver *ProfitSharingReceiver) (*ProfitSharingReceiverResponse, error)
+	// 分账 - 删除分账接收方
+	ProfitSharingRemoveReceiver(receiver *ProfitSharingReceiver) (*ProfitSharingReceiverResponse, error)
+	ProfitSharingRemoveReceiverWithContext(ctx context.Context, receiver *ProfitSharingReceiver) (*ProfitSharingReceiverResponse, error)
+	// 分账 - 单次分账
+	ProfitSharing(transactionId, outOrderNo string, receivers []ProfitSharingAmount) (*ProfitSharingResponse, error)
+	ProfitSharingWithContext(ctx context.Context, transactionId, outOrderNo string, receivers []ProfitSharingAmount) (*ProfitSharingResponse, error)
+	// 分账 - 多次分账
+	MultiProfitSharing(transactionId, outOrderNo string, receivers []ProfitSharingAmount) (*ProfitSharingResponse, error)
+	MultiProfitSharingWithContext(ctx context.Context, transactionId, outOrderNo string, receivers []ProfitSharingAmount) (*ProfitSharingResponse, error)
+	// 分账 - 查询分账结果
+	ProfitSharingQuery(transactionId, outOrderNo string) (*ProfitSharingQueryResponse, error)
+	ProfitSharingQueryWithContext(ctx context.Context, transactionId, outOrderNo string) (*ProfitSharingQueryResponse, error)
+	// 分账 - 完结分账
+	ProfitSharingFinish(transactionId, outOrderNo, description string) (*ProfitSharingResponse, error)
+	ProfitSharingFinishWithContext(ctx context.Context, transactionId, outOrderNo, description string) (*ProfitSharingResponse, error)
+	// 分账 - 分账回退
+	ProfitSharingReturn(orderId, outOrderNo, outReturnNo, returnAccount string, returnAmount int64, description string) (*ProfitSharingReturnResponse, error)
+	ProfitSharingReturnWithContext(ctx context.Context, orderId, outOrderNo, outReturnNo, returnAccount string, returnAmount int64, description string) (*ProfitSharingReturnResponse, error)
+	// 分账 - 查询分账回退结果
+	ProfitSharingReturnQuery(orderId, outOrderNo, outReturnNo string) (*ProfitSharingReturnResponse, error)
+	ProfitSharingReturnQueryWithContext(ctx context.Context, orderId, outOrderNo, outReturnNo string) (*ProfitSharingReturnResponse, error)
+
 	// 解析回调参数
 	ParseNotifyInfo(body []byte) (*NotifyInfo, error)
+	// 解析扫码支付模式一的扫码回调
+	ParseNativeCallback(body []byte) (*NativeCallbackInfo, error)
+	// 解析退款结果通知，并解密 req_info
+	ParseRefundNotify(body []byte) (*RefundNotifyInfo, error)
 }
 
-func NewUnSecureWechatPay(mchId, appId, apiSignKey string, nonceLen int, timeout time.Duration) WechatPay {
+// 创建 WechatPay 时的可选配置，如 WithSignType、WithSandbox
+type Option func(pay *wechatPay)
+
+func NewUnSecureWechatPay(mchId, appId, apiSignKey string, nonceLen int, timeout time.Duration, options ...Option) WechatPay {
 	if nonceLen > 32 {
 		nonceLen = 32
 	}
@@ -39,14 +142,19 @@ func NewUnSecureWechatPay(mchId, appId, apiSignKey string, nonceLen int, timeout
 		AppId:           appId,
 		apiSignKey:      apiSignKey,
 		NonceLen:        nonceLen,
+		signType:        SIGN_TYPE_MD5,
 		nonSecureClient: nonsecureClient,
 	}
 
+	for _, option := range options {
+		option(pay)
+	}
+
 	return pay
 
 }
 
-func NewWechatPay(mchId, appId, apiSignKey string, apiKeyFile, apiCertFile string, apiCA []byte, nonceLen int, timeout time.Duration) (WechatPay, error) {
+func NewWechatPay(mchId, appId, apiSignKey string, apiKeyFile, apiCertFile string, apiCA []byte, nonceLen int, timeout time.Duration, options ...Option) (WechatPay, error) {
 	if nonceLen > 32 {
 		nonceLen = 32
 	}
@@ -84,22 +192,36 @@ func NewWechatPay(mchId, appId, apiSignKey string, apiKeyFile, apiCertFile strin
 		apiSignKey:      apiSignKey,
 		AppId:           appId,
 		NonceLen:        nonceLen,
+		signType:        SIGN_TYPE_MD5,
 		secureClient:    client,
 		nonSecureClient: nonsecureClient,
 	}
 
+	for _, option := range options {
+		option(pay)
+	}
+
 	return pay, nil
 }
 
 type wechatPay struct {
-	mchId      string //商户号
-	AppId      string // 应用id, 商户号可以支持多个 appid, 可修改共用
-	NonceLen   int    // 随机字符串 nonce_str 长度，最长支持32字符
-	apiSignKey string //api 签名用密钥，在后台进行设置
+	mchId      string   //商户号
+	AppId      string   // 应用id, 商户号可以支持多个 appid, 可修改共用
+	NonceLen   int      // 随机字符串 nonce_str 长度，最长支持32字符
+	apiSignKey string   //api 签名用密钥，在后台进行设置
+	signType   SignType // 签名算法，用于支持 sign_type 参数的接口
 
 	apiPublicKey    string       //api 接口密钥，微信生成，通过后台下载
 	secureClient    *http.Client // 要求证书的请求
 	nonSecureClient *http.Client // 不要求证书的请求
+
+	publicKey     *rsa.PublicKey // 企业付款到银行卡使用的 RSA 公钥，首次使用时获取
+	publicKeyLock sync.Mutex
+
+	sandbox        bool          // 是否使用仿真测试系统
+	sandboxSignKey string        // 仿真测试密钥，首次调用接口或 LoadSandboxSignKey 时获取
+	sandboxLock    sync.RWMutex  // 保护 sandboxSignKey
+	sandboxFetch   chan struct{} // 保证同一时间只有一个请求获取仿真测试密钥
 }
 
 func (pay *wechatPay) GetNonceStr() string {
@@ -0,0 +1,66 @@
+package pay
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// 将微信返回的 xml 解析为 key-value 形式，只处理根节点下的一级字段
+// 用于读取 coupon_id_$n 这类无法在结构体中声明的动态字段
+func parseXMLMap(data []byte) (map[string]string, error) {
+	result := make(map[string]string)
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	depth := 0
+	name := ""
+	var value bytes.Buffer
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 2 {
+				name = t.Name.Local
+				value.Reset()
+			}
+		case xml.CharData:
+			if depth == 2 {
+				value.Write(t)
+			}
+		case xml.EndElement:
+			if depth == 2 {
+				result[name] = value.String()
+			}
+			depth--
+		}
+	}
+
+	return result, nil
+}
+
+// 读取整数字段，字段不存在或为空时返回 0
+func int64Value(values map[string]string, key string) (int64, error) {
+	value, ok := values[key]
+	if !ok || value == "" {
+		return 0, nil
+	}
+
+	result, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, errors.New(fmt.Sprintf("invalid %v: %v", key, value))
+	}
+
+	return result, nil
+}